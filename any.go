@@ -0,0 +1,50 @@
+package singleflight
+
+import (
+	"context"
+	"fmt"
+)
+
+// AnyCaller is a Caller of keys and values of any type, for routing calls of different types through a single
+// point of call sharing. Its keys must be comparable at runtime; calls for keys which are not fail with
+// ErrUncomparableKey.
+//
+// Keys of distinct types never match one another, so defining an unexported key type per kind of call keeps calls
+// of different kinds apart. CallAs and TryCallAs spare callers from asserting the types of the values they are
+// served.
+type AnyCaller = Caller[any, any]
+
+// CallAs calls fn via caller like Call does and returns the value fn returned as a T.
+//
+// In case the call it's served by was executed by a function returning values of another type, which denotes keys
+// shared by calls of different kinds, CallAs returns an error wrapping ErrTypeMismatch.
+func CallAs[T any](ctx context.Context, caller *AnyCaller, key any, fn func(context.Context) (T, error)) (T, error) {
+	v, err := caller.Call(ctx, key, func(ctx context.Context) (any, error) {
+		return fn(ctx)
+	})
+
+	return as[T](key, v, err)
+}
+
+// TryCallAs is like CallAs but it only attaches to a call already taking place, or lingering, for the given key, like
+// TryCall does.
+func TryCallAs[T any](ctx context.Context, caller *AnyCaller, key any) (T, error) {
+	v, err := caller.TryCall(ctx, key)
+
+	return as[T](key, v, err)
+}
+
+func as[T any](key, v any, err error) (T, error) {
+	if v == nil {
+		// either the execution failed or it returned the zero value of an interface type
+		var zero T
+		return zero, err
+	}
+
+	t, ok := v.(T)
+	if !ok {
+		return t, fmt.Errorf("%w: value of type %T served for key %v, expected %T", ErrTypeMismatch, v, key, t)
+	}
+
+	return t, err
+}
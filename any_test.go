@@ -0,0 +1,80 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAnyCaller(t *testing.T) {
+	t.Parallel()
+
+	type (
+		userKey  string
+		countKey string
+	)
+
+	caller := NewCaller[any, any](WithLinger(1))
+
+	name, err := CallAs(context.Background(), caller, userKey("1"), func(context.Context) (string, error) {
+		return "user", nil
+	})
+	assertNil(t, err)
+	assertEqual(t, name, "user")
+
+	// keys of distinct types do not match
+	count, err := CallAs(context.Background(), caller, countKey("1"), func(context.Context) (int, error) {
+		return 1, nil
+	})
+	assertNil(t, err)
+	assertEqual(t, count, 1)
+
+	// lingering results are served typed
+	count, err = TryCallAs[int](context.Background(), caller, countKey("1"))
+	assertNil(t, err)
+	assertEqual(t, count, 1)
+
+	// and mismatches are reported
+	_, _ = CallAs(context.Background(), caller, userKey("2"), func(context.Context) (string, error) {
+		return "user", nil
+	})
+
+	count, err = TryCallAs[int](context.Background(), caller, userKey("2"))
+	assertErrorIs(t, err, ErrTypeMismatch)
+	assertEqual(t, count, 0)
+
+	// as is the absence of calls
+	_, err = TryCallAs[int](context.Background(), caller, userKey("3"))
+	assertErrorIs(t, err, ErrNotInFlight)
+}
+
+func TestUncomparableKeys(t *testing.T) {
+	t.Parallel()
+
+	type pair struct {
+		a, b any
+	}
+
+	var caller AnyCaller
+	fn := func(context.Context) (any, error) { return 1, nil }
+
+	for _, key := range []any{[]int{1}, map[string]int{}, pair{1, []int{2}}, [1]any{func() {}}} {
+		_, err := caller.Call(context.Background(), key, fn)
+		assertErrorIs(t, err, ErrUncomparableKey)
+
+		_, err = CallAs(context.Background(), &caller, key, func(context.Context) (int, error) { return 1, nil })
+		assertErrorIs(t, err, ErrUncomparableKey)
+
+		_, _, err = caller.CallStale(context.Background(), key, fn)
+		assertErrorIs(t, err, ErrUncomparableKey)
+
+		caller.Forget(key)
+
+		_, ok := caller.KeyStats(key)
+		assertFalse(t, ok)
+	}
+
+	// the Caller remains usable
+	v, err := caller.Call(context.Background(), pair{1, 2}, fn)
+	assertNil(t, err)
+	assertEqual(t, any(1), v)
+}
@@ -0,0 +1,77 @@
+package singleflight
+
+import "context"
+
+// CallAsync is like Call but, instead of waiting for the results of the call, it arranges for callback to be
+// invoked with them once they are available. In case ctx is done first, callback is instead invoked with its cause,
+// joined with the error of the call in case the latter completed in the meantime.
+//
+// In case CallAsync ends up starting the call, fn is executed asynchronously as well, bound by ctx. Both the
+// execution and the invocation of callback are carried out on the Caller's executor, which, by default, runs
+// each task on a goroutine of its own. No goroutine is dedicated to waiting for the results.
+func (caller *Caller[K, V]) CallAsync(
+	ctx context.Context,
+	key K,
+	fn func(context.Context) (V, error),
+	callback func(V, error),
+) {
+	call, leader, err := caller.join(ctx, key, true)
+	if err != nil {
+		caller.dispatch(func() {
+			var zero V
+			callback(zero, err)
+		})
+
+		return
+	}
+
+	caller.mu.Lock()
+	if call.completed {
+		caller.mu.Unlock()
+		caller.dispatch(func() { callback(call.result()) })
+
+		return
+	}
+
+	// leaders wait for their own execution while followers stop waiting once ctx is done
+	stop := func() bool { return true }
+	if !leader {
+		stop = context.AfterFunc(ctx, func() {
+			caller.abandon(ctx, call)
+
+			caller.dispatch(func() {
+				var zero V
+				callback(zero, interrupted(ctx, call.done, call.result))
+			})
+		})
+	}
+
+	extra := call.extras()
+	extra.callbacks = append(extra.callbacks, func() {
+		if stop() {
+			caller.dispatch(func() { callback(call.result()) })
+		}
+	})
+	caller.mu.Unlock()
+
+	if leader {
+		task := func() { caller.run(ctx, call, caller.opts.timeout, fn) }
+
+		if caller.pooled() {
+			caller.submit(ctx, call, task)
+		} else {
+			caller.dispatch(task)
+		}
+	}
+}
+
+// dispatch runs task via the Caller's executor.
+func (caller *Caller[K, V]) dispatch(task func()) {
+	if caller.opts.executor != nil {
+		caller.opts.executor(task)
+
+		return
+	}
+
+	go task()
+}
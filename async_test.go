@@ -0,0 +1,76 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCallAsync(t *testing.T) {
+	t.Parallel()
+
+	const key = "key"
+
+	var (
+		tasks  int64
+		caller = NewCaller[string, int](WithExecutor(func(task func()) {
+			atomic.AddInt64(&tasks, 1)
+
+			go task()
+		}))
+		release = make(chan struct{})
+	)
+
+	fn := func(ctx context.Context) (int, error) {
+		<-release
+
+		return 1, errAssert
+	}
+
+	type result struct {
+		v   int
+		err error
+	}
+
+	var (
+		wg      sync.WaitGroup
+		results [2]result
+	)
+	wg.Add(len(results))
+
+	for i := range results {
+		i := i
+
+		caller.CallAsync(context.Background(), key, fn, func(v int, err error) {
+			defer wg.Done()
+
+			results[i] = result{v, err}
+		})
+	}
+
+	// callers whose context is done are called back with its error without waiting for the execution
+	canceled := make(chan result, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	caller.CallAsync(ctx, key, fn, func(v int, err error) {
+		canceled <- result{v, err}
+	})
+	cancel()
+
+	r := <-canceled
+	assertEqual(t, r.v, 0)
+	assertErrorIs(t, r.err, context.Canceled)
+	assertEqual(t, caller.Stats().Abandoned, 1)
+
+	close(release)
+	wg.Wait()
+
+	for _, r := range results {
+		assertEqual(t, r.v, 1)
+		assertError(t, r.err)
+	}
+
+	// the execution plus the 3 callbacks
+	assertEqual(t, atomic.LoadInt64(&tasks), 4)
+}
@@ -0,0 +1,243 @@
+// Command sfbench drives configurable workloads against a singleflight.Caller and reports their throughput, their
+// dedupe ratio and the latency percentiles callers observed.
+//
+// Usage:
+//
+//	sfbench [flags]
+//
+// Workers call the Caller in a loop for the configured duration, each picking keys out of the configured
+// distribution. Executions sleep for the configured latency, optionally jittered, and fail at the configured rate.
+// Runs are reproducible for a given seed, save for scheduling.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/azazeal/singleflight"
+)
+
+func main() {
+	cfg, err := parse(os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+
+		os.Exit(2)
+	}
+
+	cfg.report(os.Stdout, cfg.run())
+}
+
+// config describes a workload.
+type config struct {
+	keys        int
+	dist        string
+	zipfS       float64
+	concurrency int
+	duration    time.Duration
+	latency     time.Duration
+	jitter      float64
+	errorRate   float64
+	linger      int
+	seed        int64
+}
+
+func parse(args []string) (*config, error) {
+	var (
+		cfg config
+		fs  = flag.NewFlagSet("sfbench", flag.ContinueOnError)
+	)
+
+	fs.IntVar(&cfg.keys, "keys", 100, "number of distinct keys")
+	fs.StringVar(&cfg.dist, "dist", "uniform", "key distribution (uniform or zipf)")
+	fs.Float64Var(&cfg.zipfS, "zipf-s", 1.1, "skew of the zipf distribution (> 1)")
+	fs.IntVar(&cfg.concurrency, "concurrency", 64, "number of concurrent callers")
+	fs.DurationVar(&cfg.duration, "duration", 10*time.Second, "duration of the run")
+	fs.DurationVar(&cfg.latency, "latency", 5*time.Millisecond, "latency of each execution")
+	fs.Float64Var(&cfg.jitter, "jitter", 0, "fraction, in [0, 1), by which to jitter the latency of executions")
+	fs.Float64Var(&cfg.errorRate, "errors", 0, "fraction, in [0, 1], of executions which fail")
+	fs.IntVar(&cfg.linger, "linger", 0, "number of callers completed calls are held for")
+	fs.Int64Var(&cfg.seed, "seed", 1, "seed of the run")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.validate(); err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func (cfg *config) validate() error {
+	switch {
+	case cfg.keys < 1:
+		return errors.New("sfbench: -keys must be positive")
+	case cfg.dist != "uniform" && cfg.dist != "zipf":
+		return fmt.Errorf("sfbench: unknown -dist %q", cfg.dist)
+	case cfg.dist == "zipf" && cfg.zipfS <= 1:
+		return errors.New("sfbench: -zipf-s must be greater than 1")
+	case cfg.concurrency < 1:
+		return errors.New("sfbench: -concurrency must be positive")
+	case cfg.duration <= 0:
+		return errors.New("sfbench: -duration must be positive")
+	case cfg.latency < 0:
+		return errors.New("sfbench: -latency must not be negative")
+	case cfg.jitter < 0 || cfg.jitter >= 1:
+		return errors.New("sfbench: -jitter must be in [0, 1)")
+	case cfg.errorRate < 0 || cfg.errorRate > 1:
+		return errors.New("sfbench: -errors must be in [0, 1]")
+	case cfg.linger < 0:
+		return errors.New("sfbench: -linger must not be negative")
+	default:
+		return nil
+	}
+}
+
+// result describes the outcome of a run.
+type result struct {
+	elapsed    time.Duration
+	calls      uint64
+	executions uint64
+	failures   uint64
+	latencies  []time.Duration
+}
+
+var errInjected = errors.New("sfbench: injected failure")
+
+func (cfg *config) run() *result {
+	var (
+		caller     = singleflight.NewCaller[int, int](singleflight.WithLinger(cfg.linger))
+		executions atomic.Uint64
+		failures   atomic.Uint64
+
+		mu        sync.Mutex
+		latencies []time.Duration
+
+		wg sync.WaitGroup
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.duration)
+	defer cancel()
+
+	started := time.Now()
+	for i := 0; i < cfg.concurrency; i++ {
+		wg.Add(1)
+
+		go func(rng *rand.Rand) {
+			defer wg.Done()
+
+			var (
+				next     = cfg.picker(rng)
+				observed []time.Duration
+			)
+
+			// fn runs on the goroutine of the worker which leads the call, hence it may use the worker's rng.
+			fn := func(ctx context.Context) (int, error) {
+				executions.Add(1)
+
+				latency, fail := cfg.execution(rng)
+				time.Sleep(latency)
+
+				if fail {
+					return 0, errInjected
+				}
+
+				return caller.KeyFromContext(ctx), nil
+			}
+
+			for ctx.Err() == nil {
+				key := next()
+
+				start := time.Now()
+				if _, err := caller.Call(context.Background(), key, fn); err != nil {
+					failures.Add(1)
+				}
+				observed = append(observed, time.Since(start))
+			}
+
+			mu.Lock()
+			latencies = append(latencies, observed...)
+			mu.Unlock()
+		}(rand.New(rand.NewSource(cfg.seed + int64(i)))) //nolint:gosec // not security sensitive
+	}
+	wg.Wait()
+
+	return &result{
+		elapsed:    time.Since(started),
+		calls:      uint64(len(latencies)),
+		executions: executions.Load(),
+		failures:   failures.Load(),
+		latencies:  latencies,
+	}
+}
+
+// picker returns a function returning the keys of successive calls.
+func (cfg *config) picker(rng *rand.Rand) func() int {
+	if cfg.dist == "zipf" && cfg.keys > 1 {
+		zipf := rand.NewZipf(rng, cfg.zipfS, 1, uint64(cfg.keys-1))
+
+		return func() int { return int(zipf.Uint64()) }
+	}
+
+	return func() int { return rng.Intn(cfg.keys) }
+}
+
+// execution returns the latency of an execution and whether it fails.
+func (cfg *config) execution(rng *rand.Rand) (latency time.Duration, fail bool) {
+	latency = cfg.latency
+	if cfg.jitter > 0 {
+		latency += time.Duration(cfg.jitter * (2*rng.Float64() - 1) * float64(latency))
+	}
+
+	return latency, rng.Float64() < cfg.errorRate
+}
+
+func (cfg *config) report(w io.Writer, res *result) {
+	fmt.Fprintf(w, "keys=%d dist=%s concurrency=%d latency=%s jitter=%g errors=%g linger=%d seed=%d\n",
+		cfg.keys, cfg.dist, cfg.concurrency, cfg.latency, cfg.jitter, cfg.errorRate, cfg.linger, cfg.seed)
+
+	fmt.Fprintf(w, "elapsed:     %s\n", res.elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "calls:       %d (%.0f/s)\n", res.calls, float64(res.calls)/res.elapsed.Seconds())
+	fmt.Fprintf(w, "executions:  %d (%.0f/s)\n", res.executions, float64(res.executions)/res.elapsed.Seconds())
+	fmt.Fprintf(w, "failures:    %d\n", res.failures)
+
+	if res.executions > 0 {
+		fmt.Fprintf(w, "dedupe:      %.2fx\n", float64(res.calls)/float64(res.executions))
+	}
+
+	if len(res.latencies) == 0 {
+		return
+	}
+
+	slices.Sort(res.latencies)
+	for _, p := range []float64{50, 90, 99, 99.9} {
+		fmt.Fprintf(w, "p%-10g %s\n", p, percentile(res.latencies, p))
+	}
+	fmt.Fprintf(w, "max         %s\n", res.latencies[len(res.latencies)-1])
+}
+
+// percentile returns the p-th percentile of the given sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(p / 100 * float64(len(sorted)))
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+
+	return sorted[i]
+}
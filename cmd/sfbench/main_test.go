@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := parse([]string{"-keys", "10", "-dist", "zipf", "-errors", "0.5"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.keys != 10 || cfg.dist != "zipf" || cfg.errorRate != 0.5 {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+
+	for _, args := range [][]string{
+		{"-keys", "0"},
+		{"-dist", "normal"},
+		{"-dist", "zipf", "-zipf-s", "1"},
+		{"-jitter", "1"},
+		{"-errors", "2"},
+	} {
+		if _, err := parse(args); err == nil {
+			t.Errorf("expected %v to be rejected", args)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config{
+		keys:        2,
+		dist:        "uniform",
+		concurrency: 8,
+		duration:    100 * time.Millisecond,
+		latency:     10 * time.Millisecond,
+		errorRate:   1,
+		seed:        1,
+	}
+
+	res := cfg.run()
+	if res.calls == 0 || res.executions == 0 || res.executions > res.calls {
+		t.Fatalf("unexpected result: %d calls, %d executions", res.calls, res.executions)
+	}
+
+	if res.failures != res.calls {
+		t.Fatalf("expected all %d calls to fail; %d did", res.calls, res.failures)
+	}
+
+	var buf bytes.Buffer
+	cfg.report(&buf, res)
+
+	if !strings.Contains(buf.String(), "dedupe:") {
+		t.Fatalf("unexpected report:\n%s", buf.String())
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	t.Parallel()
+
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	for p, exp := range map[float64]time.Duration{0: 1, 50: 6, 90: 10, 100: 10} {
+		if got := percentile(sorted, p); got != exp {
+			t.Errorf("percentile(%g) = %d; expected %d", p, got, exp)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package singleflight
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec serializes values of type V, for sharing results across processes and persisting them.
+//
+// Implementations for other formats, such as protocol buffers, may be provided by users.
+type Codec[V any] interface {
+	// Marshal returns the encoding of v.
+	Marshal(v V) ([]byte, error)
+
+	// Unmarshal returns the value data encodes.
+	Unmarshal(data []byte) (V, error)
+}
+
+// JSONCodec is a Codec encoding values as JSON, via encoding/json.
+type JSONCodec[V any] struct{}
+
+// Marshal implements Codec.
+func (JSONCodec[V]) Marshal(v V) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal implements Codec.
+func (JSONCodec[V]) Unmarshal(data []byte) (v V, err error) {
+	err = json.Unmarshal(data, &v)
+
+	return
+}
+
+// GobCodec is a Codec encoding values as gobs, via encoding/gob. Values of interface types must have had their
+// concrete types registered with gob.Register.
+type GobCodec[V any] struct{}
+
+// Marshal implements Codec.
+func (GobCodec[V]) Marshal(v V) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal implements Codec.
+func (GobCodec[V]) Unmarshal(data []byte) (v V, err error) {
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&v)
+
+	return
+}
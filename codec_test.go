@@ -0,0 +1,41 @@
+package singleflight
+
+import "testing"
+
+type codecValue struct {
+	Name  string
+	Tags  []string
+	Count int
+}
+
+func testCodec(t *testing.T, codec Codec[codecValue]) {
+	t.Helper()
+
+	exp := codecValue{
+		Name:  "name",
+		Tags:  []string{"a", "b"},
+		Count: 2,
+	}
+
+	data, err := codec.Marshal(exp)
+	assertNil(t, err)
+
+	got, err := codec.Unmarshal(data)
+	assertNil(t, err)
+	assertDeepEqual(t, got, exp)
+
+	_, err = codec.Unmarshal([]byte("garbage"))
+	assertTrue(t, err != nil)
+}
+
+func TestJSONCodec(t *testing.T) {
+	t.Parallel()
+
+	testCodec(t, JSONCodec[codecValue]{})
+}
+
+func TestGobCodec(t *testing.T) {
+	t.Parallel()
+
+	testCodec(t, GobCodec[codecValue]{})
+}
@@ -0,0 +1,171 @@
+// Package consistenthash implements a consistent hashing ring assigning the ownership of keys to peers, for use with
+// the peer forwarding mode of singleflight Callers.
+package consistenthash
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/azazeal/singleflight"
+)
+
+// Ring assigns the ownership of keys to its members, being the local process and its peers, via consistent hashing.
+// Each member is placed on the ring a number of times, as virtual nodes, so that keys spread evenly and membership
+// changes move as few keys as possible.
+//
+// A Ring is safe for concurrent use. Its PickPeer method may be passed to singleflight.WithPeerPicker.
+type Ring[K comparable, V any] struct {
+	self     string
+	replicas int
+	keyFunc  func(K) string
+
+	mu     sync.RWMutex
+	peers  map[string]singleflight.Peer[K, V]
+	points []point // sorted by hash
+}
+
+type point struct {
+	hash   uint64
+	member string
+}
+
+// New returns a Ring on which the local process participates under the given name and every member is placed
+// replicas times. keyFunc maps keys to the strings they are hashed by; it may be nil, in which case keys are
+// formatted via fmt.Sprint.
+//
+// New panics in case replicas is less than 1.
+func New[K comparable, V any](self string, replicas int, keyFunc func(K) string) *Ring[K, V] {
+	if replicas < 1 {
+		panic(fmt.Sprintf("consistenthash: invalid number of replicas %d", replicas))
+	}
+
+	if keyFunc == nil {
+		keyFunc = func(key K) string {
+			return fmt.Sprint(key)
+		}
+	}
+
+	r := &Ring[K, V]{
+		self:     self,
+		replicas: replicas,
+		keyFunc:  keyFunc,
+	}
+	r.rebuild()
+
+	return r
+}
+
+// Set replaces the peers of the Ring with the given ones, keyed by their names. The local process remains a member
+// regardless.
+func (r *Ring[K, V]) Set(peers map[string]singleflight.Peer[K, V]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.peers = make(map[string]singleflight.Peer[K, V], len(peers))
+	for name, peer := range peers {
+		if name != r.self {
+			r.peers[name] = peer
+		}
+	}
+	r.rebuild()
+}
+
+// Add adds the named peer to the Ring, replacing any peer previously added under the same name.
+func (r *Ring[K, V]) Add(name string, peer singleflight.Peer[K, V]) {
+	if name == r.self {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.peers == nil {
+		r.peers = make(map[string]singleflight.Peer[K, V])
+	}
+	r.peers[name] = peer
+	r.rebuild()
+}
+
+// Remove removes the named peer from the Ring.
+func (r *Ring[K, V]) Remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.peers[name]; ok {
+		delete(r.peers, name)
+		r.rebuild()
+	}
+}
+
+// Owner returns the name of the member owning the given key.
+func (r *Ring[K, V]) Owner(key K) string {
+	h := hash(r.keyFunc(key))
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i, _ := slices.BinarySearchFunc(r.points, h, func(p point, h uint64) int {
+		return cmp.Compare(p.hash, h)
+	})
+	if i == len(r.points) {
+		i = 0 // wrap around
+	}
+
+	return r.points[i].member
+}
+
+// PickPeer returns the peer owning the given key. It reports false in case the local process owns it.
+func (r *Ring[K, V]) PickPeer(key K) (singleflight.Peer[K, V], bool) {
+	owner := r.Owner(key)
+	if owner == r.self {
+		return nil, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	peer, ok := r.peers[owner]
+
+	return peer, ok
+}
+
+// rebuild places the members of the Ring on it.
+//
+// r.mu must be held, unless r is not yet shared.
+func (r *Ring[K, V]) rebuild() {
+	points := make([]point, 0, (1+len(r.peers))*r.replicas)
+
+	place := func(member string) {
+		for i := 0; i < r.replicas; i++ {
+			points = append(points, point{hash(strconv.Itoa(i) + "/" + member), member})
+		}
+	}
+
+	place(r.self)
+	for name := range r.peers {
+		place(name)
+	}
+
+	// order by hash and, for the improbable collisions, by member so that all processes agree
+	slices.SortFunc(points, func(a, b point) int {
+		if c := cmp.Compare(a.hash, b.hash); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.member, b.member)
+	})
+
+	r.points = points
+}
+
+// hash returns the position of s on the ring. It is stable across processes.
+func hash(s string) uint64 {
+	sum := sha256.Sum256([]byte(s))
+
+	return binary.BigEndian.Uint64(sum[:8])
+}
@@ -0,0 +1,131 @@
+package consistenthash
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/azazeal/singleflight"
+)
+
+type peer string
+
+func (p peer) Call(context.Context, int) (string, error) {
+	return string(p), nil
+}
+
+func peers(names ...string) map[string]singleflight.Peer[int, string] {
+	m := make(map[string]singleflight.Peer[int, string], len(names))
+	for _, name := range names {
+		m[name] = peer(name)
+	}
+
+	return m
+}
+
+const keys = 10000
+
+func owners(r *Ring[int, string]) map[int]string {
+	m := make(map[int]string, keys)
+	for key := 0; key < keys; key++ {
+		m[key] = r.Owner(key)
+	}
+
+	return m
+}
+
+func TestRing(t *testing.T) {
+	t.Parallel()
+
+	r := New[int, string]("a", 64, nil)
+	r.Set(peers("a", "b", "c"))
+
+	before := owners(r)
+
+	// keys spread over all members
+	counts := make(map[string]int)
+	for _, owner := range before {
+		counts[owner]++
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		if n := counts[name]; n < keys/6 {
+			t.Errorf("expected %s to own a fair share of the keys, got %d", name, n)
+		}
+	}
+
+	// a peer's arrival only moves keys onto it
+	r.Add("d", peer("d"))
+
+	var moved int
+	for key, owner := range owners(r) {
+		if prev := before[key]; owner != prev {
+			moved++
+
+			if owner != "d" {
+				t.Fatalf("expected key %d to move to d, moved to %s", key, owner)
+			}
+		}
+	}
+	if moved == 0 || moved > keys/2 {
+		t.Errorf("expected a fraction of the keys to move, %d did", moved)
+	}
+
+	// and its departure moves them back
+	r.Remove("d")
+	for key, owner := range owners(r) {
+		if owner != before[key] {
+			t.Fatalf("expected key %d to return to %s", key, before[key])
+		}
+	}
+}
+
+func TestRingAgreement(t *testing.T) {
+	t.Parallel()
+
+	a := New[int, string]("a", 16, strconv.Itoa)
+	a.Set(peers("b", "c"))
+
+	b := New[int, string]("b", 16, strconv.Itoa)
+	b.Add("c", peer("c"))
+	b.Add("a", peer("a"))
+
+	for key := 0; key < keys; key++ {
+		if oa, ob := a.Owner(key), b.Owner(key); oa != ob {
+			t.Fatalf("expected the members to agree on the owner of %d; %s != %s", key, oa, ob)
+		}
+	}
+}
+
+func TestRingPickPeer(t *testing.T) {
+	t.Parallel()
+
+	r := New[int, string]("self", 16, nil)
+	r.Set(peers("other"))
+
+	caller := singleflight.NewCaller[int, string](singleflight.WithPeerPicker(r.PickPeer))
+
+	for key := 0; key < 100; key++ {
+		got, err := caller.Call(context.Background(), key, func(context.Context) (string, error) {
+			return "self", nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if exp := r.Owner(key); got != exp {
+			t.Errorf("expected key %d to be served by %s, got %s", key, exp, got)
+		}
+	}
+}
+
+func TestNewPanics(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+
+	_ = New[int, string]("self", 0, nil)
+}
@@ -0,0 +1,90 @@
+//go:build singleflightdebug
+
+package singleflight
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// debugging reports whether the package was built with the singleflightdebug build tag, under which it verifies its
+// internal invariants at runtime and panics with diagnostics when any of them is violated.
+const debugging = true
+
+// invariants tracks the state the invariants of a call are verified against. Its fields are guarded by the Caller's
+// mutex.
+type invariants struct {
+	unmapped  bool // whether the call has been removed from the Caller's map
+	completed bool // whether the call has completed
+	waiters   int  // number of callers attached to the call
+
+	stack []byte // stack trace of the goroutine which started the call, reported by ReentrantCallError
+}
+
+// callerInvariants tracks the state the invariants of a Caller are verified against. Its fields are guarded by the
+// Caller's mutex.
+type callerInvariants struct {
+	lastID CallID // ID of the latest call the Caller started
+}
+
+// violated panics with a diagnostic describing the violated invariant.
+func violated(format string, args ...any) {
+	panic(fmt.Sprintf("singleflight: invariant violated: "+format, args...))
+}
+
+// begin records the stack trace of the goroutine starting the call.
+func (inv *invariants) begin() {
+	inv.stack = debug.Stack()
+}
+
+// origin returns the stack trace begin recorded.
+func (inv *invariants) origin() []byte {
+	return inv.stack
+}
+
+// unmap records the removal of the call with the given ID from the Caller's map.
+func (inv *invariants) unmap(id CallID) {
+	if inv.unmapped {
+		violated("call %d removed more than once", id)
+	}
+	inv.unmapped = true
+}
+
+// complete records the completion of the call with the given ID.
+func (inv *invariants) complete(id CallID) {
+	if inv.completed {
+		violated("call %d completed more than once", id)
+	}
+	inv.completed = true
+}
+
+// attach records that a caller attached to the call with the given ID.
+func (inv *invariants) attach(id CallID) {
+	if inv.completed {
+		violated("caller attached to completed call %d", id)
+	}
+	inv.waiters++
+}
+
+// detach records that a caller detached from the call with the given ID.
+func (inv *invariants) detach(id CallID) {
+	if inv.waiters--; inv.waiters < 0 {
+		violated("call %d released by %d more callers than acquired it", id, -inv.waiters)
+	}
+}
+
+// serve records that the lingering call with the given ID served a late caller, leaving it able to serve the given
+// number of callers.
+func (*invariants) serve(id CallID, remaining int) {
+	if remaining < 0 {
+		violated("call %d may serve a negative number (%d) of late callers", id, remaining)
+	}
+}
+
+// start records that the Caller started the call with the given ID.
+func (inv *callerInvariants) start(id CallID) {
+	if id <= inv.lastID {
+		violated("call %d started after call %d", id, inv.lastID)
+	}
+	inv.lastID = id
+}
@@ -0,0 +1,87 @@
+//go:build singleflightdebug
+
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestInvariants(t *testing.T) {
+	t.Parallel()
+
+	assertPanics(t, func() {
+		var inv invariants
+		inv.unmap(1)
+		inv.unmap(1)
+	})
+
+	assertPanics(t, func() {
+		var inv invariants
+		inv.complete(1)
+		inv.complete(1)
+	})
+
+	assertPanics(t, func() {
+		var inv invariants
+		inv.complete(1)
+		inv.attach(1)
+	})
+
+	assertPanics(t, func() {
+		var inv invariants
+		inv.attach(1)
+		inv.detach(1)
+		inv.detach(1)
+	})
+
+	assertPanics(t, func() {
+		var inv invariants
+		inv.serve(1, -1)
+	})
+
+	assertPanics(t, func() {
+		var inv callerInvariants
+		inv.start(2)
+		inv.start(1)
+	})
+}
+
+func TestInvariantsHoldOnForget(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller  Caller[string, int]
+		release = make(chan struct{})
+	)
+
+	f := caller.Begin(context.Background(), "key", func(ctx context.Context) (int, error) {
+		<-release
+
+		return 1, nil
+	})
+
+	caller.Forget("key")
+	caller.Forget("key")
+	caller.Purge()
+	close(release)
+
+	v, err := f.Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, v, 1)
+}
+
+func TestReentrantCallOrigin(t *testing.T) {
+	t.Parallel()
+
+	var caller Caller[string, int]
+
+	_, err := caller.Call(context.Background(), "key", func(ctx context.Context) (int, error) {
+		return caller.Call(ctx, "key", nil)
+	})
+
+	var reentrant *ReentrantCallError
+	assertTrue(t, errors.As(err, &reentrant))
+	assertTrue(t, len(reentrant.Origin) > 0)
+}
@@ -0,0 +1,111 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// The errors below denote failures of the call sharing mechanism itself, as opposed to errors returned by the
+// functions whose calls are shared. They may be matched via errors.Is.
+var (
+	// ErrNotInFlight is returned by TryCall when there's no call to attach to.
+	ErrNotInFlight = errors.New("singleflight: no call in flight")
+
+	// ErrWouldMissDeadline is returned by Callers configured WithDeadlineAdmission to callers which, based on the
+	// estimated duration of the in-flight call they would otherwise attach to, would miss their deadline waiting
+	// for it.
+	//
+	// ErrWouldMissDeadline wraps context.DeadlineExceeded.
+	ErrWouldMissDeadline = fmt.Errorf("singleflight: in-flight call unlikely to complete in time: %w",
+		context.DeadlineExceeded)
+
+	// ErrTypeMismatch is wrapped by the errors CallAs and TryCallAs return when served values of types other than
+	// the expected ones.
+	ErrTypeMismatch = errors.New("singleflight: type mismatch")
+
+	// ErrPanicked is wrapped by the PanicError the callers attached to a call whose execution panicked are served.
+	ErrPanicked = errors.New("singleflight: execution panicked")
+
+	// ErrQueueTimeout is served to the callers attached to calls which remained queued on the pool of a Caller
+	// configured WithWorkers for longer than the timeout configured WithQueueTimeout.
+	ErrQueueTimeout = errors.New("singleflight: execution timed out in queue")
+
+	// ErrLeaderStalled is returned by Callers configured WithStallTimeout to the callers waiting for the results of
+	// executions which stalled.
+	ErrLeaderStalled = errors.New("singleflight: execution stalled")
+
+	// ErrReentrantCall is wrapped by the ReentrantCallError executions calling, directly or transitively, into their
+	// own Caller for their own key are served, rather than waiting for themselves forever.
+	ErrReentrantCall = errors.New("singleflight: reentrant call")
+
+	// ErrClosed is wrapped by the errors Callers bound to contexts via NewCallerWithContext fail calls with once their
+	// contexts are done.
+	ErrClosed = errors.New("singleflight: caller closed")
+
+	// ErrUncomparableKey is returned to callers whose keys, being of interface types such as the ones of an
+	// AnyCaller, hold values of uncomparable dynamic types, such as slices, and may therefore not be shared.
+	ErrUncomparableKey = errors.New("singleflight: uncomparable key")
+
+	// ErrSnapshotVersion is wrapped by the errors LoadSnapshot returns for snapshots of unsupported versions.
+	ErrSnapshotVersion = errors.New("singleflight: unsupported snapshot version")
+)
+
+// PanicError is the error the callers attached to a call are served in case its execution panics. The caller which
+// executed the call panics with it, once the rest have been served, rather than returning it.
+//
+// PanicError wraps ErrPanicked.
+type PanicError struct {
+	// Value is the value the execution panicked with.
+	Value any
+
+	// Stack is the stack trace of the goroutine the execution panicked on.
+	Stack []byte
+}
+
+// Error implements error for PanicError.
+func (err *PanicError) Error() string {
+	return fmt.Sprintf("%v: %v\n\n%s", ErrPanicked, err.Value, err.Stack)
+}
+
+// Unwrap returns ErrPanicked.
+func (*PanicError) Unwrap() error {
+	return ErrPanicked
+}
+
+// ReentrantCallError is the error executions calling, directly or transitively, into their own Caller for their own
+// key are served, since the call they would otherwise attach to could only complete once they return.
+//
+// ReentrantCallError wraps ErrReentrantCall.
+type ReentrantCallError struct {
+	// Key is the key of the call.
+	Key any
+
+	// ID identifies the call.
+	ID CallID
+
+	// Stack is the stack trace of the goroutine the reentrant call was made on.
+	Stack []byte
+
+	// Origin is the stack trace of the goroutine which started the call. It is only recorded under the
+	// singleflightdebug build tag, since recording it for every call is costly, and is nil otherwise. Error hints
+	// at the tag when it is.
+	Origin []byte
+}
+
+// Error implements error for ReentrantCallError.
+func (err *ReentrantCallError) Error() string {
+	msg := fmt.Sprintf("%v: key %v of call %d\n\n%s", ErrReentrantCall, err.Key, err.ID, err.Stack)
+	if len(err.Origin) > 0 {
+		msg += fmt.Sprintf("\ncall %d started at:\n\n%s", err.ID, err.Origin)
+	} else {
+		msg += fmt.Sprintf("\n(build with -tags singleflightdebug to record where call %d started)", err.ID)
+	}
+
+	return msg
+}
+
+// Unwrap returns ErrReentrantCall.
+func (*ReentrantCallError) Unwrap() error {
+	return ErrReentrantCall
+}
@@ -0,0 +1,56 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPanic(t *testing.T) {
+	t.Parallel()
+
+	const key = "key"
+
+	var caller Caller[string, bool]
+
+	fn := func(context.Context) (bool, error) {
+		time.Sleep(shortPause)
+
+		panic("boom")
+	}
+
+	var (
+		wg        sync.WaitGroup
+		recovered any
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() { recovered = recover() }()
+
+		_, _ = caller.Call(context.Background(), key, fn)
+	}()
+	time.Sleep(shortPause >> 2)
+
+	// followers are served the panic as an error
+	_, err := caller.Call(context.Background(), key, fn)
+	assertErrorIs(t, err, ErrPanicked)
+
+	var pe *PanicError
+	assertTrue(t, errors.As(err, &pe))
+	assertEqual(t, pe.Value, any("boom"))
+
+	// while the leader panics with it
+	wg.Wait()
+	assertEqual(t, recovered, any(pe))
+
+	// and the key is usable again
+	got, err := caller.Call(context.Background(), key, func(context.Context) (bool, error) {
+		return true, nil
+	})
+	assertTrue(t, got)
+	assertNil(t, err)
+}
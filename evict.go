@@ -0,0 +1,165 @@
+package singleflight
+
+import "time"
+
+// EvictReason is the reason a call was removed from a Caller, as reported to the callback configured WithOnEvict.
+type EvictReason int
+
+const (
+	// EvictForgotten denotes calls removed via Forget.
+	EvictForgotten EvictReason = iota + 1
+
+	// EvictPurged denotes calls removed via Purge.
+	EvictPurged
+
+	// EvictExhausted denotes completed calls removed after serving the number of late callers configured
+	// WithLinger.
+	EvictExhausted
+
+	// EvictExpired denotes completed calls removed, upon being looked up, after lingering for the duration
+	// configured WithTTL or WithTTLFor.
+	EvictExpired
+
+	// EvictStalled denotes in-flight calls removed because their executions stalled, as detected by Callers
+	// configured WithStallTimeout.
+	EvictStalled
+
+	// EvictSuperseded denotes in-flight calls replaced by newer ones, started by callers whose contexts did not
+	// tolerate their age, as set via MaxAgeContext, as well as completed calls whose held results were replaced via
+	// Fill.
+	EvictSuperseded
+)
+
+// String implements fmt.Stringer for EvictReason.
+func (reason EvictReason) String() string {
+	switch reason {
+	case EvictForgotten:
+		return "forgotten"
+	case EvictPurged:
+		return "purged"
+	case EvictExhausted:
+		return "exhausted"
+	case EvictExpired:
+		return "expired"
+	case EvictStalled:
+		return "stalled"
+	case EvictSuperseded:
+		return "superseded"
+	default:
+		return "unknown"
+	}
+}
+
+// WithOnEvict configures the Caller to invoke fn with the key of every call removed from it, either in flight or
+// lingering, along with the reason for the removal. Calls removed upon completing, because they should not linger,
+// are not reported.
+//
+// fn is invoked synchronously, once the Caller is no longer locked, by the goroutine removing the call.
+func WithOnEvict[K comparable](fn func(key K, reason EvictReason)) Option {
+	return keyOption[K](func(opts *keyOptions[K]) {
+		opts.onEvict = fn
+	})
+}
+
+// sweepAfter removes the given completed call, in case it is still held, once the given duration elapses, so that
+// held calls expire even when their keys are not looked up again.
+func (caller *Caller[K, V]) sweepAfter(call *call[K, V], d time.Duration) {
+	time.AfterFunc(d, func() {
+		caller.mu.Lock()
+		expired := caller.calls[call.key] == call
+		if expired {
+			delete(caller.calls, call.key)
+			call.inv.unmap(call.id)
+		}
+		caller.mu.Unlock()
+
+		if expired {
+			caller.evicted(call.key, EvictExpired)
+		}
+	})
+}
+
+// Forget removes the call for the given key, in case one exists, so that subsequent calls for the key are executed
+// anew. Callers already attached to a call taking place keep waiting for its results.
+func (caller *Caller[K, V]) Forget(key K) {
+	if !hashable(key) {
+		return
+	}
+
+	caller.mu.Lock()
+	call, ok := caller.calls[key]
+	if ok {
+		delete(caller.calls, key)
+		call.inv.unmap(call.id)
+	}
+	caller.mu.Unlock()
+
+	if ok {
+		caller.evicted(key, EvictForgotten)
+	}
+}
+
+// ForgetFunc removes the calls for the keys match reports true for, as if Forget had been called for each of them.
+func (caller *Caller[K, V]) ForgetFunc(match func(key K) bool) {
+	caller.mu.Lock()
+	calls := make(map[K]*call[K, V], len(caller.calls))
+	for key, call := range caller.calls {
+		calls[key] = call
+	}
+	caller.mu.Unlock()
+
+	// match is user code; call it without holding the mutex
+	for key := range calls {
+		if !match(key) {
+			delete(calls, key)
+		}
+	}
+
+	if len(calls) == 0 {
+		return
+	}
+
+	forgotten := make([]K, 0, len(calls))
+
+	caller.mu.Lock()
+	for key, call := range calls {
+		// skip calls which have been removed in the meantime
+		if caller.calls[key] == call {
+			delete(caller.calls, key)
+			call.inv.unmap(call.id)
+
+			forgotten = append(forgotten, key)
+		}
+	}
+	caller.mu.Unlock()
+
+	for _, key := range forgotten {
+		caller.evicted(key, EvictForgotten)
+	}
+}
+
+// Purge removes all calls, as if Forget had been called for each of their keys.
+func (caller *Caller[K, V]) Purge() {
+	caller.mu.Lock()
+	calls := caller.calls
+	caller.calls = nil
+	if debugging {
+		for _, call := range calls {
+			call.inv.unmap(call.id)
+		}
+	}
+	caller.mu.Unlock()
+
+	for key := range calls {
+		caller.evicted(key, EvictPurged)
+	}
+}
+
+// evicted reports the removal of the call for the given key.
+//
+// caller.mu must not be held.
+func (caller *Caller[K, V]) evicted(key K, reason EvictReason) {
+	if fn := caller.keyOpts.onEvict; fn != nil {
+		fn(key, reason)
+	}
+}
@@ -0,0 +1,152 @@
+package singleflight
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestEvict(t *testing.T) {
+	t.Parallel()
+
+	type eviction struct {
+		key    string
+		reason EvictReason
+	}
+
+	var (
+		mu        sync.Mutex
+		evictions []eviction
+	)
+
+	caller := NewCaller[string, int64](
+		WithLinger(2),
+		WithOnEvict(func(key string, reason EvictReason) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			evictions = append(evictions, eviction{key, reason})
+		}),
+	)
+
+	var executions int64
+	fn := func(context.Context) (int64, error) {
+		return atomic.AddInt64(&executions, 1), nil
+	}
+
+	call := func(key string, exp int64) {
+		t.Helper()
+
+		got, err := caller.Call(context.Background(), key, fn)
+		assertNil(t, err)
+		assertEqual(t, got, exp)
+	}
+
+	// exhaust a lingering call
+	call("a", 1)
+	call("a", 1)
+	call("a", 1)
+
+	// forget a lingering call
+	call("b", 2)
+	caller.Forget("b")
+	caller.Forget("b")
+	call("b", 3)
+
+	// purge the remaining ones
+	call("c", 4)
+	caller.Purge()
+	call("c", 5)
+
+	exp := []eviction{
+		{"a", EvictExhausted},
+		{"b", EvictForgotten},
+		{"b", EvictPurged},
+		{"c", EvictPurged},
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assertEqual(t, len(evictions), len(exp))
+	for _, e := range exp {
+		var found bool
+		for _, got := range evictions {
+			found = found || got == e
+		}
+		assertTrue(t, found)
+	}
+}
+
+func TestForgetInFlight(t *testing.T) {
+	t.Parallel()
+
+	const key = "key"
+
+	var (
+		caller  Caller[string, int]
+		release = make(chan struct{})
+	)
+
+	f1 := caller.Begin(context.Background(), key, func(context.Context) (int, error) {
+		<-release
+
+		return 1, nil
+	})
+	caller.Forget(key)
+
+	got, err := caller.Call(context.Background(), key, func(context.Context) (int, error) {
+		return 2, nil
+	})
+	assertNil(t, err)
+	assertEqual(t, got, 2)
+
+	close(release)
+
+	got, err = f1.Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, got, 1)
+}
+
+func TestWithOnEvictPanics(t *testing.T) {
+	t.Parallel()
+
+	assertPanics(t, func() {
+		_ = NewCaller[int, bool](WithOnEvict(func(string, EvictReason) {}))
+	})
+}
+
+func TestForgetFunc(t *testing.T) {
+	t.Parallel()
+
+	var forgotten []int
+
+	caller := NewCaller[int, int](
+		WithLinger(10),
+		WithOnEvict(func(key int, reason EvictReason) {
+			assertEqual(t, reason, EvictForgotten)
+
+			forgotten = append(forgotten, key)
+		}),
+	)
+
+	for key := 0; key < 4; key++ {
+		_, err := caller.Call(context.Background(), key, func(context.Context) (int, error) { return 0, nil })
+		assertNil(t, err)
+	}
+
+	caller.ForgetFunc(func(key int) bool { return key%2 == 0 })
+	slices.Sort(forgotten)
+	assertDeepEqual(t, forgotten, []int{0, 2})
+
+	for key := 0; key < 4; key++ {
+		_, err := caller.TryCall(context.Background(), key)
+		if key%2 == 0 {
+			assertErrorIs(t, err, ErrNotInFlight)
+		} else {
+			assertNil(t, err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package singleflight
+
+import "context"
+
+// filler is implemented by calls of keys of type K and values of type V.
+type filler[K comparable, V any] interface {
+	fill(key K, v V) bool
+}
+
+// Fill serves v, as the successful result of the call for the given key, to the Caller of the execution ctx belongs
+// to, the innermost one in case executions are nested, so that functions computing the values of related keys as a
+// by-product, such as ones fetching pages of records, may spare those keys executions of their own.
+//
+// In case a call for the key is taking place, Fill completes it with v, serving the callers waiting for it like
+// Publish would; the results its execution returns are discarded. Otherwise, in case the Caller holds results, as
+// configured WithLinger, WithTTL or WithTTLFor, Fill holds v for the callers to come in place of any results held for
+// the key already. It reports whether it did either, which it does not in case ctx belongs to no execution, the
+// execution is of keys or values of other types or the Caller holds no results.
+func Fill[K comparable, V any](ctx context.Context, key K, v V) bool {
+	if call, ok := ctx.Value(executionContextKey{}).(filler[K, V]); ok {
+		return call.fill(key, v)
+	}
+
+	return false
+}
+
+func (call *call[K, V]) fill(key K, v V) bool {
+	return call.caller.fill(key, v)
+}
+
+// fill implements Fill for the Caller.
+func (caller *Caller[K, V]) fill(key K, v V) bool {
+	if !hashable(key) {
+		return false
+	}
+
+	ttl := caller.ttl(key, v, nil)
+	oversized := caller.oversized(v, ttl)
+
+	caller.mu.Lock()
+
+	if inflight, ok := caller.calls[key]; ok && !inflight.completed {
+		caller.mu.Unlock()
+
+		return caller.complete(inflight, v, nil, nil)
+	}
+
+	if oversized {
+		caller.track(key, func(s *Stats) { s.Oversized++ })
+		caller.mu.Unlock()
+
+		return false
+	}
+
+	filled := &call[K, V]{
+		caller:    caller,
+		key:       key,
+		id:        nextCallID(),
+		val:       v,
+		completed: true,
+	}
+	filled.inv.complete(filled.id)
+
+	if caller.opts.keepStale {
+		caller.keep(filled)
+	}
+
+	if !filled.hold(caller.opts.linger, ttl) {
+		caller.mu.Unlock()
+
+		return caller.opts.keepStale
+	}
+
+	if caller.calls == nil {
+		caller.calls = make(map[K]*call[K, V], caller.opts.capacity)
+	}
+
+	held, superseded := caller.calls[key]
+	if superseded {
+		held.inv.unmap(held.id)
+	}
+	caller.calls[key] = filled
+	caller.inv.start(filled.id)
+
+	caller.mu.Unlock()
+
+	if superseded {
+		caller.evicted(key, EvictSuperseded)
+	}
+
+	return true
+}
@@ -0,0 +1,98 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestFill(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller  = NewCaller[int, string](WithLinger(1))
+		entered = make(chan struct{})
+		release = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+
+	// a caller waits for the results of a call for a sibling key
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		v, err := caller.Call(context.Background(), 2, func(context.Context) (string, error) {
+			close(entered)
+			<-release
+
+			return "discarded", nil
+		})
+		assertNil(t, err)
+		assertEqual(t, "two", v)
+	}()
+	<-entered
+
+	v, err := caller.Call(context.Background(), 1, func(ctx context.Context) (string, error) {
+		assertTrue(t, Fill(ctx, 2, "two"))
+		assertTrue(t, Fill(ctx, 3, "three"))
+
+		// fills of other types are ignored
+		assertFalse(t, Fill(ctx, "4", "four"))
+
+		return "one", nil
+	})
+	assertNil(t, err)
+	assertEqual(t, "one", v)
+
+	close(release)
+	wg.Wait()
+
+	// held results are served to the callers to come
+	v, err = caller.TryCall(context.Background(), 3)
+	assertNil(t, err)
+	assertEqual(t, "three", v)
+}
+
+func TestFillSupersedesHeldResults(t *testing.T) {
+	t.Parallel()
+
+	var (
+		evicted []EvictReason
+		caller  = NewCaller[int, int](WithLinger(2), WithOnEvict(func(_ int, reason EvictReason) {
+			evicted = append(evicted, reason)
+		}))
+	)
+
+	_, err := caller.Call(context.Background(), 2, func(context.Context) (int, error) { return 1, nil })
+	assertNil(t, err)
+
+	_, err = caller.Call(context.Background(), 1, func(ctx context.Context) (int, error) {
+		assertTrue(t, Fill(ctx, 2, 2))
+
+		return 1, nil
+	})
+	assertNil(t, err)
+
+	v, err := caller.TryCall(context.Background(), 2)
+	assertNil(t, err)
+	assertEqual(t, 2, v)
+	assertDeepEqual(t, []EvictReason{EvictSuperseded}, evicted)
+}
+
+func TestFillWithoutHolding(t *testing.T) {
+	t.Parallel()
+
+	var caller Caller[int, int]
+
+	_, err := caller.Call(context.Background(), 1, func(ctx context.Context) (int, error) {
+		assertFalse(t, Fill(ctx, 2, 2))
+
+		return 1, nil
+	})
+	assertNil(t, err)
+
+	_, err = caller.TryCall(context.Background(), 2)
+	assertErrorIs(t, err, ErrNotInFlight)
+
+	assertFalse(t, Fill(context.Background(), 2, 2))
+}
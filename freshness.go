@@ -0,0 +1,40 @@
+package singleflight
+
+import (
+	"context"
+	"time"
+)
+
+// WithMaxAges configures the Caller to record the times its calls start at so that it honors the maximum ages the
+// contexts of callers carry, as set via MaxAgeContext. Callers not configured WithMaxAges ignore them, sparing every
+// call reading the clock.
+func WithMaxAges() Option {
+	return optionFunc(func(opts *options) {
+		opts.maxAges = true
+	})
+}
+
+type maxAgeContextKeyType struct{}
+
+// MaxAgeContext returns a copy of ctx carrying the given maximum age. Callers whose contexts carry a maximum age only
+// attach to in-flight calls started at most that long ago, provided the Caller is configured WithMaxAges. Older in-flight calls are left to serve the callers
+// already attached to them, while the caller starts a new call which subsequent callers attach to instead. The
+// replaced calls are reported to the callback configured WithOnEvict as EvictSuperseded.
+//
+// The maximum age does not apply to completed calls lingering around, which WithLinger, WithTTL and WithTTLFor
+// govern, nor to TryCall, which starts no calls.
+func MaxAgeContext(ctx context.Context, maxAge time.Duration) context.Context {
+	return context.WithValue(ctx, maxAgeContextKeyType{}, maxAge)
+}
+
+// tooOld reports whether a call started at the given time is older than the maximum age ctx carries, if any. Calls
+// whose start time was not recorded are never deemed too old.
+func tooOld(ctx context.Context, started time.Time) bool {
+	if started.IsZero() {
+		return false
+	}
+
+	maxAge, ok := ctx.Value(maxAgeContextKeyType{}).(time.Duration)
+
+	return ok && time.Since(started) > maxAge
+}
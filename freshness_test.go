@@ -0,0 +1,100 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMaxAgeContext(t *testing.T) {
+	t.Parallel()
+
+	var (
+		evictions = make(chan EvictReason, 1)
+		caller    = NewCaller[string, int](WithMaxAges(), WithOnEvict(func(_ string, reason EvictReason) {
+			evictions <- reason
+		}))
+		release = make(chan struct{})
+	)
+
+	old := caller.Begin(context.Background(), "key", func(context.Context) (int, error) {
+		<-release
+
+		return 1, nil
+	})
+
+	// callers tolerating the age of the in-flight call attach to it
+	tolerant := caller.Begin(MaxAgeContext(context.Background(), time.Hour), "key", nil)
+
+	time.Sleep(shortPause)
+
+	// callers which do not tolerate it start a new call
+	v, err := caller.Call(MaxAgeContext(context.Background(), shortPause/2), "key", func(context.Context) (int, error) {
+		return 2, nil
+	})
+	assertNil(t, err)
+	assertEqual(t, v, 2)
+	assertEqual(t, <-evictions, EvictSuperseded)
+
+	close(release)
+
+	for _, f := range []*Future[int]{old, tolerant} {
+		v, err := f.Await(context.Background())
+		assertNil(t, err)
+		assertEqual(t, v, 1)
+	}
+}
+
+func TestMaxAgeContextAttachesToFreshCalls(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller  = NewCaller[string, int](WithMaxAges())
+		release = make(chan struct{})
+	)
+
+	f := caller.Begin(context.Background(), "key", func(context.Context) (int, error) {
+		<-release
+
+		return 1, nil
+	})
+
+	follower := caller.Begin(MaxAgeContext(context.Background(), time.Hour), "key", nil)
+	close(release)
+
+	for _, f := range []*Future[int]{f, follower} {
+		v, err := f.Await(context.Background())
+		assertNil(t, err)
+		assertEqual(t, v, 1)
+	}
+
+	assertEqual(t, caller.Stats().Followers, uint64(1))
+}
+
+func TestMaxAgeContextWithoutMaxAges(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller  Caller[string, int]
+		release = make(chan struct{})
+	)
+
+	f := caller.Begin(context.Background(), "key", func(context.Context) (int, error) {
+		<-release
+
+		return 1, nil
+	})
+	time.Sleep(shortPause)
+
+	// Callers not configured WithMaxAges ignore the maximum ages of contexts
+	follower := caller.Begin(MaxAgeContext(context.Background(), time.Nanosecond), "key", nil)
+	close(release)
+
+	for _, f := range []*Future[int]{f, follower} {
+		v, err := f.Await(context.Background())
+		assertNil(t, err)
+		assertEqual(t, v, 1)
+	}
+
+	assertEqual(t, caller.Stats().Followers, uint64(1))
+}
@@ -0,0 +1,136 @@
+package singleflight
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Future is the handle of a shared call, as returned by Begin.
+type Future[V any] struct {
+	done <-chan struct{}
+	call futureCall[V]
+	err  error // the error joining the call failed with, if any
+
+	ctx  context.Context //nolint:containedctx // the context the call was joined with
+	left atomic.Bool     // whether the caller has been detached from the call
+}
+
+// futureCall is implemented by calls of values of type V.
+type futureCall[V any] interface {
+	result() (V, error)
+	leave(ctx context.Context, abandoned bool)
+}
+
+// Begin is like Call but, instead of waiting for the results of the call, it returns a Future they may be
+// collected from later on. In case Begin ends up starting the call, fn is executed on a separate goroutine.
+//
+// ctx bounds the execution of fn in case Begin ends up starting the call, but not the wait for its results;
+// the context passed to Await does.
+func (caller *Caller[K, V]) Begin(ctx context.Context, key K, fn func(context.Context) (V, error)) *Future[V] {
+	call, leader, err := caller.join(ctx, key, true)
+	if err != nil {
+		return &Future[V]{
+			done: closedChan,
+			err:  err,
+		}
+	}
+
+	caller.mu.Lock()
+	done := call.done
+	if done == nil {
+		if call.completed {
+			// the call lingers around and may be shared by callers reading its done channel; leave it alone
+			done = closedChan
+		} else {
+			call.done = make(chan struct{})
+			done = call.done
+		}
+	}
+	caller.mu.Unlock()
+
+	if leader {
+		task := func() { caller.run(ctx, call, caller.opts.timeout, fn) }
+
+		if caller.pooled() {
+			caller.submit(ctx, call, task)
+		} else {
+			go task()
+		}
+	}
+
+	return &Future[V]{
+		done: done,
+		call: call,
+		ctx:  ctx,
+	}
+}
+
+// closedChan is the done channel of calls which complete before anyone waits on them.
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+
+	return ch
+}()
+
+// Done returns a channel which is closed once the results of the call are available.
+func (f *Future[V]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Await waits for and returns the results of the call. In case ctx is done first, Await returns its cause instead,
+// joined with the error of the call in case the latter completed in the meantime, and the caller is no longer counted
+// as waiting for the call, as reported by WaitersBelow, even in case it awaits it again.
+func (f *Future[V]) Await(ctx context.Context) (V, error) {
+	select {
+	case <-f.done:
+		return f.results()
+	default:
+	}
+
+	select {
+	case <-f.done:
+		return f.results()
+	case <-ctx.Done():
+		f.leave(true)
+
+		var zero V
+		return zero, interrupted(ctx, f.done, f.results)
+	}
+}
+
+// leave detaches the caller from the call, unless it has been detached already or the call completed.
+func (f *Future[V]) leave(abandoned bool) {
+	select {
+	case <-f.done:
+		return
+	default:
+	}
+
+	if f.left.CompareAndSwap(false, true) {
+		f.call.leave(f.ctx, abandoned)
+	}
+}
+
+// TryGet returns the results of the call, without waiting for them. It reports false in case they are not yet
+// available.
+func (f *Future[V]) TryGet() (V, error, bool) { //nolint:revive // reporting availability last reads naturally
+	select {
+	case <-f.done:
+		v, err := f.results()
+
+		return v, err, true
+	default:
+		var zero V
+		return zero, nil, false
+	}
+}
+
+func (f *Future[V]) results() (V, error) {
+	if f.err != nil {
+		var zero V
+		return zero, f.err
+	}
+
+	return f.call.result()
+}
@@ -0,0 +1,104 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFuture(t *testing.T) {
+	t.Parallel()
+
+	const key = "key"
+
+	var (
+		caller     Caller[string, int64]
+		executions int64
+		release    = make(chan struct{})
+	)
+
+	fn := func(ctx context.Context) (int64, error) {
+		<-release
+
+		return atomic.AddInt64(&executions, 1), errAssert
+	}
+
+	f1 := caller.Begin(context.Background(), key, fn)
+	f2 := caller.Begin(context.Background(), key, fn)
+
+	_, _, ok := f1.TryGet()
+	assertFalse(t, ok)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := f2.Await(ctx)
+	assertErrorIs(t, err, context.Canceled)
+
+	close(release)
+	<-f1.Done()
+
+	got, err, ok := f1.TryGet()
+	assertTrue(t, ok)
+	assertEqual(t, got, 1)
+	assertError(t, err)
+
+	got, err = f2.Await(context.Background())
+	assertEqual(t, got, 1)
+	assertError(t, err)
+
+	assertEqual(t, atomic.LoadInt64(&executions), 1)
+}
+
+func TestFutureLingering(t *testing.T) {
+	t.Parallel()
+
+	caller := NewCaller[string, int](WithLinger(1))
+
+	fn := func(context.Context) (int, error) {
+		return 1, nil
+	}
+
+	_, _ = caller.Call(context.Background(), "key", fn)
+
+	got, err, ok := caller.Begin(context.Background(), "key", fn).TryGet()
+	assertTrue(t, ok)
+	assertEqual(t, got, 1)
+	assertNil(t, err)
+}
+
+func TestFutureLingeringConcurrently(t *testing.T) {
+	t.Parallel()
+
+	caller := NewCaller[int, int](WithLinger(1000))
+	fn := func(context.Context) (int, error) { return 1, nil }
+
+	// Begin must not touch the lingering calls the callers racing it read
+	for key := 0; key < 64; key++ {
+		_, err := caller.Call(context.Background(), key, fn)
+		assertNil(t, err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(2)
+
+			go func(key int) {
+				defer wg.Done()
+
+				v, err := caller.Call(context.Background(), key, fn)
+				assertNil(t, err)
+				assertEqual(t, 1, v)
+			}(key)
+
+			go func(key int) {
+				defer wg.Done()
+
+				v, err := caller.Begin(context.Background(), key, fn).Await(context.Background())
+				assertNil(t, err)
+				assertEqual(t, 1, v)
+			}(key)
+		}
+		wg.Wait()
+	}
+}
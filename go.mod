@@ -1,5 +1,3 @@
 module github.com/azazeal/singleflight
 
-go 1.18
-
-require golang.org/x/sync v0.10.0
+go 1.21
@@ -0,0 +1,33 @@
+package singleflight
+
+import "reflect"
+
+// hashable reports whether key may be looked up in a map, which keys of interface types, or of types embedding
+// interfaces, holding values of uncomparable dynamic types, such as slices, may not; looking them up panics.
+func hashable[K comparable](key K) bool {
+	t := reflect.TypeOf(any(key))
+	switch {
+	case t == nil:
+		return true
+	case !t.Comparable():
+		return false
+	case t.Kind() == reflect.Struct, t.Kind() == reflect.Array:
+		// their fields or elements may be interfaces holding uncomparable values
+		return comparesEqual(key)
+	default:
+		return true
+	}
+}
+
+// comparesEqual reports whether comparing v to itself does not panic.
+func comparesEqual(v any) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
+	_ = v == v //nolint:gocritic // the comparison may panic, which is what is being checked
+
+	return true
+}
@@ -0,0 +1,103 @@
+package singleflight
+
+import (
+	"context"
+	"time"
+)
+
+// WithStallTimeout configures the Caller to detect executions which stall, being executions which go for longer than
+// the given timeout without emitting a heartbeat via Heartbeat, such as ones whose goroutines are wedged. The start of
+// an execution counts as a heartbeat.
+//
+// Callers waiting for the results of stalled executions via Call, CallWithTimeout, CallWithInfo or TryCall stop
+// waiting and are served ErrLeaderStalled. The call of a stalled execution is removed from the Caller, so that
+// subsequent callers start a new execution, and reported to the callback configured WithOnEvict as EvictStalled.
+//
+// Functions whose executions may take longer than the timeout should call Heartbeat periodically. A timeout of zero
+// or less disables stall detection.
+func WithStallTimeout(timeout time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.stallTimeout = timeout
+	})
+}
+
+// heartbeater is implemented by calls.
+type heartbeater interface {
+	heartbeat()
+}
+
+func (call *call[K, V]) heartbeat() {
+	call.beat.Store(time.Now().UnixNano())
+}
+
+// Heartbeat records that the execution ctx belongs to, the innermost one in case executions are nested, is making
+// progress, for Callers configured WithStallTimeout. It is a no-op in case ctx belongs to no execution.
+func Heartbeat(ctx context.Context) {
+	if call, ok := ctx.Value(executionContextKey{}).(heartbeater); ok {
+		call.heartbeat()
+	}
+}
+
+// watch is like wait but it additionally detects whether the execution of the call stalls.
+func (caller *Caller[K, V]) watch(ctx context.Context, call *call[K, V]) (V, error) {
+	timeout := caller.opts.stallTimeout
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-call.done:
+			return call.result()
+		case <-ctx.Done():
+			caller.abandon(ctx, call)
+
+			var zero V
+			return zero, interrupted(ctx, call.done, call.result)
+		case <-timer.C:
+		}
+
+		// the execution may not have started yet, in case it's queued on the pool
+		if beat := call.beat.Load(); beat == 0 {
+			timer.Reset(timeout)
+
+			continue
+		} else if since := time.Since(time.Unix(0, beat)); since < timeout {
+			timer.Reset(timeout - since)
+
+			continue
+		}
+
+		select {
+		case <-call.done:
+			// the execution completed in the meantime
+			return call.result()
+		default:
+		}
+
+		caller.stalled(ctx, call)
+
+		var zero V
+		return zero, ErrLeaderStalled
+	}
+}
+
+// stalled records that the caller ctx belongs to stopped waiting for the results of the given call because its
+// execution stalled, and removes the call from the Caller in case it has not been removed already.
+func (caller *Caller[K, V]) stalled(ctx context.Context, call *call[K, V]) {
+	identity := caller.identify(ctx)
+
+	caller.mu.Lock()
+	call.detach(identity)
+
+	removed := !call.completed && caller.calls[call.key] == call
+	if removed {
+		delete(caller.calls, call.key)
+		call.inv.unmap(call.id)
+	}
+	caller.mu.Unlock()
+
+	if removed {
+		caller.evicted(call.key, EvictStalled)
+	}
+}
@@ -0,0 +1,81 @@
+package singleflight
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithStallTimeout(t *testing.T) {
+	t.Parallel()
+
+	var (
+		evicted atomic.Int32
+		caller  = NewCaller[string, int](
+			WithStallTimeout(shortPause),
+			WithOnEvict(func(_ string, reason EvictReason) {
+				assertEqual(t, reason, EvictStalled)
+				evicted.Add(1)
+			}),
+		)
+		entered = make(chan struct{})
+		release = make(chan struct{})
+	)
+	defer close(release)
+
+	f := caller.Begin(context.Background(), "key", func(context.Context) (int, error) {
+		close(entered)
+		<-release
+
+		return 1, nil
+	})
+	<-entered
+
+	start := time.Now()
+
+	_, err := caller.Call(context.Background(), "key", nil)
+	assertErrorIs(t, err, ErrLeaderStalled)
+	assertTrue(t, time.Since(start) >= shortPause/2)
+	assertEqual(t, evicted.Load(), int32(1))
+
+	// the stalled call was removed; subsequent callers start anew
+	v, err := caller.Call(context.Background(), "key", func(context.Context) (int, error) { return 2, nil })
+	assertNil(t, err)
+	assertEqual(t, v, 2)
+
+	_, _, ok := f.TryGet()
+	assertFalse(t, ok)
+}
+
+func TestHeartbeat(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller  = NewCaller[string, int](WithStallTimeout(shortPause))
+		entered = make(chan struct{})
+	)
+
+	f := caller.Begin(context.Background(), "key", func(ctx context.Context) (int, error) {
+		close(entered)
+
+		for i := 0; i < 6; i++ {
+			time.Sleep(shortPause / 2)
+			Heartbeat(ctx)
+		}
+
+		return 1, nil
+	})
+	<-entered
+
+	v, err := caller.Call(context.Background(), "key", nil)
+	assertNil(t, err)
+	assertEqual(t, v, 1)
+
+	v, err = f.Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, v, 1)
+
+	// heartbeats outside of executions are no-ops
+	Heartbeat(context.Background())
+}
@@ -0,0 +1,72 @@
+package singleflight
+
+import (
+	"fmt"
+	"slices"
+)
+
+// WithHistory configures the Caller to record the descriptions of the latest n executions it completes, as reported
+// by History, so that what happened around an incident may be reconstructed after the fact. The descriptions are
+// kept in a ring buffer; recording an execution once n have been recorded drops the oldest one.
+//
+// WithHistory panics in case n is negative. A history of zero executions denotes no history at all.
+func WithHistory(n int) Option {
+	if n < 0 {
+		panic(fmt.Sprintf("singleflight: invalid history size %d", n))
+	}
+
+	return optionFunc(func(opts *options) {
+		opts.history = n
+	})
+}
+
+// record records the given execution in the history of the Caller.
+//
+// caller.mu must be held.
+func (caller *Caller[K, V]) record(exec Execution[K]) {
+	exec.Consumers = slices.Clone(exec.Consumers)
+
+	if len(caller.history) < caller.opts.history {
+		caller.history = append(caller.history, exec)
+
+		return
+	}
+
+	caller.history[caller.historyNext] = exec
+	caller.historyNext = (caller.historyNext + 1) % len(caller.history)
+}
+
+// History returns the descriptions of the latest executions the Caller completed, oldest first, for Callers
+// configured WithHistory.
+func (caller *Caller[K, V]) History() []Execution[K] {
+	caller.mu.Lock()
+	defer caller.mu.Unlock()
+
+	history := make([]Execution[K], 0, len(caller.history))
+	history = append(history, caller.history[caller.historyNext:]...)
+	history = append(history, caller.history[:caller.historyNext]...)
+
+	return history
+}
+
+// AnyHistory is like History but it reports the keys of the executions as values of type any, so that the histories
+// of Callers of different key types may be handled alike, as the administration handlers built on top of the
+// registry do.
+func (caller *Caller[K, V]) AnyHistory() []Execution[any] {
+	history := caller.History()
+
+	anyHistory := make([]Execution[any], len(history))
+	for i, exec := range history {
+		anyHistory[i] = Execution[any]{
+			Key:       exec.Key,
+			ID:        exec.ID,
+			Started:   exec.Started,
+			Duration:  exec.Duration,
+			Err:       exec.Err,
+			Followers: exec.Followers,
+			Consumers: exec.Consumers,
+		}
+	}
+
+	return anyHistory
+}
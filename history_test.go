@@ -0,0 +1,92 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	caller := NewCaller[int, int](WithHistory(3))
+	assertEqual(t, 0, len(caller.History()))
+
+	errOdd := errors.New("odd")
+	for i := 0; i < 5; i++ {
+		_, _ = caller.Call(context.Background(), i, func(ctx context.Context) (int, error) {
+			if i%2 == 1 {
+				return 0, errOdd
+			}
+
+			return i, nil
+		})
+	}
+
+	history := caller.History()
+	assertEqual(t, 3, len(history))
+
+	for i, exec := range history {
+		assertEqual(t, i+2, exec.Key)
+		assertTrue(t, !exec.Started.IsZero())
+
+		if exec.Key%2 == 1 {
+			assertErrorIs(t, exec.Err, errOdd)
+		} else {
+			assertNil(t, exec.Err)
+		}
+	}
+
+	for i := 1; i < len(history); i++ {
+		assertTrue(t, history[i-1].ID < history[i].ID)
+	}
+
+	anyHistory := caller.AnyHistory()
+	assertEqual(t, len(history), len(anyHistory))
+	for i := range anyHistory {
+		assertEqual(t, any(history[i].Key), anyHistory[i].Key)
+		assertEqual(t, history[i].ID, anyHistory[i].ID)
+	}
+}
+
+func TestHistoryFollowers(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller  = NewCaller[string, int](WithHistory(1))
+		release = make(chan struct{})
+	)
+
+	f := caller.Begin(context.Background(), "key", func(context.Context) (int, error) {
+		<-release
+
+		return 1, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		_ = caller.Begin(context.Background(), "key", nil)
+	}
+	close(release)
+
+	_, err := f.Await(context.Background())
+	assertNil(t, err)
+
+	history := caller.History()
+	assertEqual(t, 1, len(history))
+	assertEqual(t, 2, history[0].Followers)
+}
+
+func TestWithoutHistory(t *testing.T) {
+	t.Parallel()
+
+	var caller Caller[string, int]
+	_, _ = caller.Call(context.Background(), "key", func(context.Context) (int, error) { return 1, nil })
+
+	assertEqual(t, 0, len(caller.History()))
+}
+
+func TestWithHistoryPanicsOnNegativeSizes(t *testing.T) {
+	t.Parallel()
+
+	assertPanics(t, func() { _ = WithHistory(-1) })
+}
@@ -0,0 +1,108 @@
+package singleflight
+
+import (
+	"context"
+	"slices"
+)
+
+// WithIdentity configures the Caller to identify each caller by the identity fn extracts from its context, such as
+// the name of the user or the service it acts on behalf of. An empty identity denotes an anonymous caller.
+//
+// The identities of the callers served by each execution are reported to the callback configured WithOnComplete,
+// and the ones of the callers later served its results, while held around, to the one configured WithOnConsume, for
+// auditing purposes.
+func WithIdentity(fn func(ctx context.Context) string) Option {
+	return optionFunc(func(opts *options) {
+		opts.identify = fn
+	})
+}
+
+// identify returns the identity of the caller ctx belongs to.
+func (caller *Caller[K, V]) identify(ctx context.Context) string {
+	if fn := caller.opts.identify; fn != nil {
+		return fn(ctx)
+	}
+
+	return ""
+}
+
+// attach records that the caller with the given identity is attached to the call.
+//
+// The Caller's mutex must be held.
+func (call *call[K, V]) attach(identity string) {
+	call.inv.attach(call.id)
+	call.waiters++
+
+	if identity == "" {
+		return
+	}
+
+	extra := call.extras()
+	if extra.consumers == nil {
+		extra.consumers = make(map[string]int)
+	}
+	extra.consumers[identity]++
+}
+
+// detach records that the caller with the given identity is no longer attached to the call.
+//
+// The Caller's mutex must be held.
+func (call *call[K, V]) detach(identity string) {
+	call.inv.detach(call.id)
+	if call.waiters--; call.extra != nil {
+		call.crossed()
+	}
+
+	if identity == "" {
+		return
+	}
+
+	if consumers := call.extra.consumers; consumers[identity] <= 1 {
+		delete(consumers, identity)
+	} else {
+		consumers[identity]--
+	}
+}
+
+// identities returns the distinct identities of the callers attached to the call, in order.
+//
+// The Caller's mutex must be held.
+func (call *call[K, V]) identities() []string {
+	if call.extra == nil || len(call.extra.consumers) == 0 {
+		return nil
+	}
+
+	identities := make([]string, 0, len(call.extra.consumers))
+	for identity := range call.extra.consumers {
+		identities = append(identities, identity)
+	}
+	slices.Sort(identities)
+
+	return identities
+}
+
+// identifier is implemented by calls.
+type identifier interface {
+	attachedIdentities() []string
+}
+
+func (call *call[K, V]) attachedIdentities() []string {
+	call.caller.mu.Lock()
+	defer call.caller.mu.Unlock()
+
+	return call.identities()
+}
+
+// Identities returns, sorted, the distinct identities of the callers attached to the execution ctx belongs to, the
+// innermost one in case executions are nested, for Callers configured WithIdentity. Functions whose calls are
+// shared may use them to scope their work to what the callers waiting for it are entitled to.
+//
+// The callers attached to an execution may change while it takes place; Identities reports the ones attached at the
+// time of the call. It returns nil in case ctx belongs to no execution.
+func Identities(ctx context.Context) []string {
+	if call, ok := ctx.Value(executionContextKey{}).(identifier); ok {
+		return call.attachedIdentities()
+	}
+
+	return nil
+}
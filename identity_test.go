@@ -0,0 +1,129 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type identityKey struct{}
+
+func identityContext(identity string) context.Context {
+	return context.WithValue(context.Background(), identityKey{}, identity)
+}
+
+func identityOf(ctx context.Context) string {
+	identity, _ := ctx.Value(identityKey{}).(string)
+
+	return identity
+}
+
+func TestIdentity(t *testing.T) {
+	t.Parallel()
+
+	const key = "key"
+
+	var (
+		completed = make(chan Execution[string], 1)
+		caller    = NewCaller[string, bool](
+			WithIdentity(identityOf),
+			WithOnComplete(func(e Execution[string]) { completed <- e }),
+		)
+		release = make(chan struct{})
+	)
+
+	f := caller.Begin(identityContext("carol"), key, func(context.Context) (bool, error) {
+		<-release
+
+		return true, nil
+	})
+
+	var wg sync.WaitGroup
+	for _, identity := range []string{"bob", "alice", "bob", "", "dave"} {
+		ctx, cancel := context.WithCancel(identityContext(identity))
+		if identity == "dave" {
+			cancel()
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer cancel()
+
+			_, _ = caller.Call(ctx, key, nil)
+		}()
+	}
+
+	// wait for everyone to attach, and dave to leave, before completing the execution
+	for s := caller.Stats(); s.Followers < 5 || s.Abandoned < 1; s = caller.Stats() {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	wg.Wait()
+	_, _ = f.Await(context.Background())
+
+	e := <-completed
+	assertDeepEqual(t, e.Consumers, []string{"alice", "bob", "carol"})
+}
+
+func TestIdentityOfLateConsumers(t *testing.T) {
+	t.Parallel()
+
+	var (
+		completed = make(chan Execution[string], 1)
+		consumed  []Consumption[string]
+		caller    = NewCaller[string, int](
+			WithIdentity(identityOf),
+			WithLinger(2),
+			WithOnComplete(func(e Execution[string]) { completed <- e }),
+			WithOnConsume(func(c Consumption[string]) { consumed = append(consumed, c) }),
+		)
+	)
+
+	for _, identity := range []string{"alice", "bob", ""} {
+		v, err := caller.Call(identityContext(identity), "key", func(context.Context) (int, error) { return 1, nil })
+		assertNil(t, err)
+		assertEqual(t, 1, v)
+	}
+
+	e := <-completed
+	assertDeepEqual(t, e.Consumers, []string{"alice"})
+	assertDeepEqual(t, consumed, []Consumption[string]{
+		{Key: "key", ID: e.ID, Identity: "bob"},
+		{Key: "key", ID: e.ID},
+	})
+}
+
+func TestIdentities(t *testing.T) {
+	t.Parallel()
+
+	const key = "key"
+
+	var (
+		caller  = NewCaller[string, []string](WithIdentity(identityOf))
+		release = make(chan struct{})
+	)
+
+	f := caller.Begin(identityContext("bob"), key, func(ctx context.Context) ([]string, error) {
+		<-release
+
+		return Identities(ctx), nil
+	})
+
+	ctx, cancel := context.WithCancel(identityContext("alice"))
+	defer cancel()
+
+	f2 := caller.Begin(ctx, key, nil)
+
+	close(release)
+
+	got, err := f.Await(context.Background())
+	assertNil(t, err)
+	assertDeepEqual(t, got, []string{"alice", "bob"})
+
+	_, _ = f2.Await(context.Background())
+
+	assertEqual(t, len(Identities(context.Background())), 0)
+}
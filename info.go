@@ -0,0 +1,122 @@
+package singleflight
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// CallID uniquely identifies an execution, across all Callers of the process.
+type CallID uint64
+
+var lastCallID atomic.Uint64
+
+func nextCallID() CallID {
+	return CallID(lastCallID.Add(1))
+}
+
+// CallInfo describes the call a caller was served by.
+type CallInfo struct {
+	// ID identifies the call.
+	ID CallID
+
+	// Leader reports whether the caller executed the call, as opposed to having attached to it.
+	Leader bool
+
+	// Meta holds the metadata the execution attached to its results via SetMeta, in case the caller was served
+	// them. It is shared by every caller of the call and must not be modified.
+	Meta map[string]any
+}
+
+// CallWithInfo is like Call but it additionally returns information on the call the caller was served by. The
+// information is zero in case the caller could not attach to any call.
+func (caller *Caller[K, V]) CallWithInfo(
+	ctx context.Context,
+	key K,
+	fn func(context.Context) (V, error),
+) (V, CallInfo, error) {
+	return caller.do(ctx, key, caller.opts.timeout, fn)
+}
+
+// executionContextKey is the key the contexts of executions carry their call under.
+type executionContextKey struct{}
+
+// identified is implemented by calls.
+type identified interface {
+	identity() CallID
+}
+
+func (call *call[K, V]) identity() CallID {
+	return call.id
+}
+
+// CallIDFromContext returns the ID of the execution ctx belongs to, the innermost one in case executions are nested.
+// It reports false in case ctx belongs to none.
+func CallIDFromContext(ctx context.Context) (CallID, bool) {
+	if call, ok := ctx.Value(executionContextKey{}).(identified); ok {
+		return call.identity(), true
+	}
+
+	return 0, false
+}
+
+// Execution describes a completed execution, as reported to the callback configured WithOnComplete.
+type Execution[K comparable] struct {
+	// Key is the key of the call.
+	Key K
+
+	// ID identifies the call.
+	ID CallID
+
+	// Started is the time the call started at.
+	Started time.Time
+
+	// Duration is the time the execution took.
+	Duration time.Duration
+
+	// Err is the error the execution resulted in.
+	Err error
+
+	// Followers is the number of callers which attached to the call while the execution took place.
+	Followers int
+
+	// Consumers lists, sorted, the distinct identities of the callers served by the execution upon its
+	// completion, for Callers configured WithIdentity. Callers which stopped waiting for the execution before it
+	// completed are not included, and neither are the ones later served by the completed call lingering around,
+	// which are reported to the callback configured WithOnConsume instead.
+	Consumers []string
+}
+
+// Consumption describes a caller served the results of a completed call, as reported to the callback configured
+// WithOnConsume.
+type Consumption[K comparable] struct {
+	// Key is the key of the call.
+	Key K
+
+	// ID identifies the call.
+	ID CallID
+
+	// Identity is the identity of the caller, for Callers configured WithIdentity.
+	Identity string
+}
+
+// WithOnConsume configures the Caller to invoke fn for every caller it serves the results of a completed call held
+// around, such as the ones configured WithLinger and WithTTL hold. Such callers attach to calls once their
+// executions have been reported to the callback configured WithOnComplete, so fn complements the Consumers of the
+// executions it is passed, for auditing purposes.
+//
+// fn is invoked synchronously, once the Caller is no longer locked, by the goroutine of the caller.
+func WithOnConsume[K comparable](fn func(Consumption[K])) Option {
+	return keyOption[K](func(opts *keyOptions[K]) {
+		opts.onConsume = fn
+	})
+}
+
+// WithOnComplete configures the Caller to invoke fn with the description of every execution it completes.
+//
+// fn is invoked synchronously, once the Caller is no longer locked, by the goroutine completing the execution.
+func WithOnComplete[K comparable](fn func(Execution[K])) Option {
+	return keyOption[K](func(opts *keyOptions[K]) {
+		opts.onComplete = fn
+	})
+}
@@ -0,0 +1,73 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCallWithInfo(t *testing.T) {
+	t.Parallel()
+
+	const key = "key"
+
+	var (
+		completed = make(chan Execution[string], 1)
+		caller    = NewCaller[string, CallID](WithOnComplete(func(e Execution[string]) {
+			completed <- e
+		}))
+	)
+
+	fn := func(ctx context.Context) (CallID, error) {
+		time.Sleep(shortPause)
+
+		id, ok := CallIDFromContext(ctx)
+		assertTrue(t, ok)
+
+		return id, errAssert
+	}
+
+	var (
+		wg    sync.WaitGroup
+		infos [2]CallInfo
+		ids   [2]CallID
+	)
+
+	for i := range infos {
+		i := i
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if i > 0 {
+				time.Sleep(shortPause >> 2)
+			}
+
+			ids[i], infos[i], _ = caller.CallWithInfo(context.Background(), key, fn)
+		}()
+	}
+	wg.Wait()
+
+	assertTrue(t, infos[0].Leader)
+	assertFalse(t, infos[1].Leader)
+	assertTrue(t, infos[0].ID != 0)
+	assertEqual(t, infos[1].ID, infos[0].ID)
+	assertEqual(t, ids[0], infos[0].ID)
+	assertEqual(t, ids[1], infos[0].ID)
+
+	e := <-completed
+	assertEqual(t, e.Key, key)
+	assertEqual(t, e.ID, infos[0].ID)
+	assertTrue(t, e.Duration >= shortPause)
+	assertError(t, e.Err)
+
+	// subsequent executions are identified anew
+	_, info, _ := caller.CallWithInfo(context.Background(), key, fn)
+	assertTrue(t, info.Leader)
+	assertTrue(t, info.ID > infos[0].ID)
+
+	_, ok := CallIDFromContext(context.Background())
+	assertFalse(t, ok)
+}
@@ -0,0 +1,42 @@
+package singleflight
+
+import "context"
+
+// InvalidateFrom forgets, like Forget does, the calls for the keys received over keys, such as the keys of the
+// invalidation events an external bus delivers upon upstream writes, so that the results the Caller holds remain
+// consistent with the data they derive from.
+//
+// InvalidateFrom blocks until ctx is done, in which case it returns its cause, or keys is closed, in which case it
+// returns nil.
+func (caller *Caller[K, V]) InvalidateFrom(ctx context.Context, keys <-chan K) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+
+			caller.Forget(key)
+		}
+	}
+}
+
+// InvalidateFuncFrom is like InvalidateFrom but it forgets, like ForgetFunc does, the calls for the keys each of the
+// predicates received over matches reports true for, such as the ones invalidation events covering sets of keys
+// translate to.
+func (caller *Caller[K, V]) InvalidateFuncFrom(ctx context.Context, matches <-chan func(key K) bool) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case match, ok := <-matches:
+			if !ok {
+				return nil
+			}
+
+			caller.ForgetFunc(match)
+		}
+	}
+}
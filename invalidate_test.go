@@ -0,0 +1,60 @@
+package singleflight
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestInvalidateFrom(t *testing.T) {
+	t.Parallel()
+
+	caller := NewCaller[string, int](WithLinger(10))
+	for _, key := range []string{"a", "b"} {
+		_, err := caller.Call(context.Background(), key, func(context.Context) (int, error) { return 0, nil })
+		assertNil(t, err)
+	}
+
+	keys := make(chan string, 1)
+	keys <- "a"
+	close(keys)
+
+	assertNil(t, caller.InvalidateFrom(context.Background(), keys))
+
+	_, err := caller.TryCall(context.Background(), "a")
+	assertErrorIs(t, err, ErrNotInFlight)
+
+	_, err = caller.TryCall(context.Background(), "b")
+	assertNil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assertErrorIs(t, caller.InvalidateFrom(ctx, make(chan string)), context.Canceled)
+}
+
+func TestInvalidateFuncFrom(t *testing.T) {
+	t.Parallel()
+
+	caller := NewCaller[string, int](WithLinger(10))
+	for _, key := range []string{"user:1", "user:2", "order:1"} {
+		_, err := caller.Call(context.Background(), key, func(context.Context) (int, error) { return 0, nil })
+		assertNil(t, err)
+	}
+
+	matches := make(chan func(string) bool, 1)
+	matches <- func(key string) bool { return strings.HasPrefix(key, "user:") }
+	close(matches)
+
+	assertNil(t, caller.InvalidateFuncFrom(context.Background(), matches))
+
+	for key, held := range map[string]bool{"user:1": false, "user:2": false, "order:1": true} {
+		_, err := caller.TryCall(context.Background(), key)
+		assertEqual(t, err == nil, held)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assertErrorIs(t, caller.InvalidateFuncFrom(ctx, make(chan func(string) bool)), context.Canceled)
+}
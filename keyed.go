@@ -0,0 +1,50 @@
+package singleflight
+
+import "strings"
+
+// StringCaller is a Caller of string keys. It spares call sites the key type parameter and hosts helpers specific to
+// string keys, such as ForgetPrefix.
+//
+// The zero value of a StringCaller is ready to use. Like CallerE, StringCaller embeds the Caller it wraps.
+type StringCaller[V any] struct {
+	Caller[string, V]
+}
+
+// NewStringCaller returns a StringCaller configured with the given options.
+func NewStringCaller[V any](opts ...Option) *StringCaller[V] {
+	caller := new(StringCaller[V])
+	caller.Caller.configure(opts)
+
+	return caller
+}
+
+// ForgetPrefix removes the calls for the keys starting with the given prefix, as if Forget had been called for each
+// of them.
+func (caller *StringCaller[V]) ForgetPrefix(prefix string) {
+	caller.ForgetFunc(func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
+
+// IntCaller is a Caller of int keys. It spares call sites the key type parameter and hosts helpers specific to int
+// keys, such as ForgetRange.
+//
+// The zero value of an IntCaller is ready to use. Like CallerE, IntCaller embeds the Caller it wraps.
+type IntCaller[V any] struct {
+	Caller[int, V]
+}
+
+// NewIntCaller returns an IntCaller configured with the given options.
+func NewIntCaller[V any](opts ...Option) *IntCaller[V] {
+	caller := new(IntCaller[V])
+	caller.Caller.configure(opts)
+
+	return caller
+}
+
+// ForgetRange removes the calls for the keys in the [from, to) range, as if Forget had been called for each of them.
+func (caller *IntCaller[V]) ForgetRange(from, to int) {
+	caller.ForgetFunc(func(key int) bool {
+		return from <= key && key < to
+	})
+}
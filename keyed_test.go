@@ -0,0 +1,80 @@
+package singleflight
+
+import (
+	"context"
+	"strconv"
+	"testing"
+)
+
+func TestStringCaller(t *testing.T) {
+	t.Parallel()
+
+	caller := NewStringCaller[int](WithLinger(2), WithCapacity(4))
+
+	var executions int
+	fn := func(ctx context.Context) (int, error) {
+		executions++
+
+		return len(caller.KeyFromContext(ctx)), nil
+	}
+
+	for _, key := range []string{"user:1", "user:2", "group:1"} {
+		v, err := caller.Call(context.Background(), key, fn)
+		assertNil(t, err)
+		assertEqual(t, len(key), v)
+	}
+
+	caller.ForgetPrefix("user:")
+
+	for _, key := range []string{"user:1", "user:2", "group:1"} {
+		_, err := caller.Call(context.Background(), key, fn)
+		assertNil(t, err)
+	}
+	assertEqual(t, 5, executions)
+}
+
+func TestIntCaller(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller     = NewIntCaller[string](WithLinger(2))
+		executions int
+	)
+
+	fn := func(ctx context.Context) (string, error) {
+		executions++
+
+		return strconv.Itoa(caller.KeyFromContext(ctx)), nil
+	}
+
+	for key := 0; key < 4; key++ {
+		v, err := caller.Call(context.Background(), key, fn)
+		assertNil(t, err)
+		assertEqual(t, strconv.Itoa(key), v)
+	}
+
+	caller.ForgetRange(1, 3)
+
+	for key := 0; key < 4; key++ {
+		_, err := caller.Call(context.Background(), key, fn)
+		assertNil(t, err)
+	}
+	assertEqual(t, 6, executions)
+}
+
+func TestZeroKeyedCallers(t *testing.T) {
+	t.Parallel()
+
+	var (
+		s StringCaller[int]
+		i IntCaller[int]
+	)
+
+	v, err := s.Call(context.Background(), "key", func(context.Context) (int, error) { return 1, nil })
+	assertNil(t, err)
+	assertEqual(t, 1, v)
+
+	v, err = i.Call(context.Background(), 1, func(context.Context) (int, error) { return 2, nil })
+	assertNil(t, err)
+	assertEqual(t, 2, v)
+}
@@ -0,0 +1,54 @@
+package singleflight
+
+import "context"
+
+// metaSetter is implemented by calls.
+type metaSetter interface {
+	setMeta(key string, value any)
+}
+
+func (call *call[K, V]) setMeta(key string, value any) {
+	call.caller.mu.Lock()
+	defer call.caller.mu.Unlock()
+
+	if call.completed {
+		// the metadata has been served already
+		return
+	}
+
+	extra := call.extras()
+	if extra.meta == nil {
+		extra.meta = make(map[string]any)
+	}
+	extra.meta[key] = value
+}
+
+// metadata returns the metadata of the call, in case it has completed. Since the metadata of completed calls is
+// immutable, callers which were served the results of the call may access it without holding the Caller's mutex.
+func (call *call[K, V]) metadata() map[string]any {
+	if call.done != nil {
+		select {
+		case <-call.done:
+		default:
+			return nil
+		}
+	}
+
+	if call.extra == nil {
+		return nil
+	}
+
+	return call.extra.meta
+}
+
+// SetMeta attaches the given metadata, such as the source of the results or the time they were fetched at, to the
+// results of the execution ctx belongs to, the innermost one in case executions are nested, replacing any metadata
+// previously attached under the same key. The metadata is served, along with the results, to every caller of the
+// call via CallInfo.Meta.
+//
+// SetMeta is a no-op in case ctx belongs to no execution or the execution has completed.
+func SetMeta(ctx context.Context, key string, value any) {
+	if call, ok := ctx.Value(executionContextKey{}).(metaSetter); ok {
+		call.setMeta(key, value)
+	}
+}
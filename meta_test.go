@@ -0,0 +1,79 @@
+package singleflight
+
+import (
+	"context"
+	"runtime"
+	"testing"
+)
+
+func TestSetMeta(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller  = NewCaller[string, int](WithLinger(1))
+		entered = make(chan struct{})
+		release = make(chan struct{})
+	)
+
+	f := caller.Begin(context.Background(), "key", func(ctx context.Context) (int, error) {
+		SetMeta(ctx, "source", "origin")
+		SetMeta(ctx, "version", 1)
+		close(entered)
+		<-release
+		SetMeta(ctx, "version", 2)
+
+		return 1, nil
+	})
+	<-entered
+
+	type result struct {
+		info CallInfo
+		err  error
+	}
+
+	results := make(chan result)
+	go func() {
+		_, info, err := caller.CallWithInfo(context.Background(), "key", nil)
+		results <- result{info, err}
+	}()
+
+	for caller.Stats().Followers == 0 {
+		runtime.Gosched()
+	}
+	close(release)
+
+	exp := map[string]any{"source": "origin", "version": 2}
+
+	r := <-results
+	assertNil(t, r.err)
+	assertFalse(t, r.info.Leader)
+	assertDeepEqual(t, r.info.Meta, exp)
+
+	_, err := f.Await(context.Background())
+	assertNil(t, err)
+
+	// the lingering call serves its metadata as well
+	_, info, err := caller.CallWithInfo(context.Background(), "key", nil)
+	assertNil(t, err)
+	assertDeepEqual(t, info.Meta, exp)
+
+	// leaders are served the metadata of their own executions
+	_, info, err = caller.CallWithInfo(context.Background(), "other", func(ctx context.Context) (int, error) {
+		SetMeta(ctx, "source", "cache")
+
+		return 0, nil
+	})
+	assertNil(t, err)
+	assertTrue(t, info.Leader)
+	assertDeepEqual(t, info.Meta, map[string]any{"source": "cache"})
+
+	// calls without metadata serve none
+	_, info, err = caller.CallWithInfo(context.Background(), "none", func(context.Context) (int, error) {
+		return 0, nil
+	})
+	assertNil(t, err)
+	assertTrue(t, info.Meta == nil)
+
+	// metadata set outside of executions is ignored
+	SetMeta(context.Background(), "source", "nowhere")
+}
@@ -0,0 +1,24 @@
+//go:build !singleflightdebug
+
+package singleflight
+
+// debugging reports whether the package was built with the singleflightdebug build tag, under which it verifies its
+// internal invariants at runtime and panics with diagnostics when any of them is violated.
+const debugging = false
+
+// invariants and callerInvariants are only tracked under the singleflightdebug build tag; they occupy no space
+// otherwise.
+type (
+	invariants       struct{}
+	callerInvariants struct{}
+)
+
+func (*invariants) begin()            {}
+func (*invariants) origin() []byte    { return nil }
+func (*invariants) unmap(CallID)      {}
+func (*invariants) complete(CallID)   {}
+func (*invariants) attach(CallID)     {}
+func (*invariants) detach(CallID)     {}
+func (*invariants) serve(CallID, int) {}
+
+func (*callerInvariants) start(CallID) {}
@@ -0,0 +1,92 @@
+package singleflight
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// once carries out a function until it first succeeds, sharing the executions of concurrent callers, and serves its
+// value from then on.
+type once[T any] struct {
+	caller Caller[struct{}, T]
+	done   atomic.Bool
+	v      T // set once before done
+}
+
+func (o *once[T]) do(ctx context.Context, f func(context.Context) (T, error)) (T, error) {
+	if o.done.Load() {
+		return o.v, nil
+	}
+
+	return o.caller.Call(ctx, struct{}{}, func(ctx context.Context) (T, error) {
+		if o.done.Load() {
+			// a previous execution succeeded while the caller was attaching
+			return o.v, nil
+		}
+
+		v, err := f(ctx)
+		if err == nil {
+			o.v = v
+			o.done.Store(true)
+		}
+
+		return v, err
+	})
+}
+
+// OnceFuncCtx is like sync.OnceFunc but for functions which may fail and should observe contexts. The returned
+// function calls f until it first succeeds, returning nil without calling f from then on. Concurrent calls share the
+// execution of f, which is passed the context of the call which started it, like Call does; calls whose contexts are
+// done before it completes return their causes. Failures, including panics, are not remembered, so the calls after
+// them call f anew.
+//
+// Unlike sync.OnceFunc, which re-panics with the value f panicked with, a panic of f reaches the call which executed
+// it as a panic with a *PanicError carrying that value, and the calls sharing the execution as a *PanicError error,
+// like it does for Call.
+func OnceFuncCtx(f func(ctx context.Context) error) func(ctx context.Context) error {
+	var o once[struct{}]
+
+	fn := func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, f(ctx)
+	}
+
+	return func(ctx context.Context) error {
+		_, err := o.do(ctx, fn)
+
+		return err
+	}
+}
+
+// OnceValueCtx is like OnceFuncCtx but for functions returning a value, which the returned function returns from
+// the first time f succeeds on, like sync.OnceValue does.
+func OnceValueCtx[T any](f func(ctx context.Context) (T, error)) func(ctx context.Context) (T, error) {
+	var o once[T]
+
+	return func(ctx context.Context) (T, error) {
+		return o.do(ctx, f)
+	}
+}
+
+// OnceValuesCtx is like OnceValueCtx but for functions returning two values, like sync.OnceValues does.
+func OnceValuesCtx[T1, T2 any](
+	f func(ctx context.Context) (T1, T2, error),
+) func(ctx context.Context) (T1, T2, error) {
+	type values struct {
+		v1 T1
+		v2 T2
+	}
+
+	var o once[values]
+
+	fn := func(ctx context.Context) (values, error) {
+		v1, v2, err := f(ctx)
+
+		return values{v1, v2}, err
+	}
+
+	return func(ctx context.Context) (T1, T2, error) {
+		v, err := o.do(ctx, fn)
+
+		return v.v1, v.v2, err
+	}
+}
@@ -0,0 +1,133 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOnceValueCtx(t *testing.T) {
+	t.Parallel()
+
+	var (
+		calls   atomic.Int32
+		errOnce = errors.New("once")
+	)
+
+	get := OnceValueCtx(func(context.Context) (int, error) {
+		// the first call fails; the rest succeed
+		if n := calls.Add(1); n == 1 {
+			return 0, errOnce
+		}
+		time.Sleep(shortPause)
+
+		return 42, nil
+	})
+
+	_, err := get(context.Background())
+	assertErrorIs(t, err, errOnce)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			v, err := get(context.Background())
+			assertNil(t, err)
+			assertEqual(t, 42, v)
+		}()
+	}
+	wg.Wait()
+
+	v, err := get(context.Background())
+	assertNil(t, err)
+	assertEqual(t, 42, v)
+	assertEqual(t, int32(2), calls.Load())
+}
+
+func TestOnceValueCtxCancellation(t *testing.T) {
+	t.Parallel()
+
+	var (
+		entered = make(chan struct{})
+		release = make(chan struct{})
+	)
+
+	get := OnceValueCtx(func(context.Context) (int, error) {
+		close(entered)
+		<-release
+
+		return 1, nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		v, err := get(context.Background())
+		assertNil(t, err)
+		assertEqual(t, 1, v)
+	}()
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), shortPause)
+	defer cancel()
+
+	_, err := get(ctx)
+	assertErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	<-done
+}
+
+func TestOnceFuncCtx(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+	do := OnceFuncCtx(func(context.Context) error {
+		if calls++; calls == 1 {
+			panic("boom")
+		}
+
+		return nil
+	})
+
+	func() {
+		defer func() {
+			pe, ok := recover().(*PanicError)
+			assertTrue(t, ok)
+			assertEqual(t, any("boom"), pe.Value)
+		}()
+
+		_ = do(context.Background())
+	}()
+
+	for i := 0; i < 2; i++ {
+		assertNil(t, do(context.Background()))
+	}
+	assertEqual(t, 2, calls)
+}
+
+func TestOnceValuesCtx(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+	get := OnceValuesCtx(func(context.Context) (string, int, error) {
+		calls++
+
+		return "a", 1, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		s, n, err := get(context.Background())
+		assertNil(t, err)
+		assertEqual(t, "a", s)
+		assertEqual(t, 1, n)
+	}
+	assertEqual(t, 1, calls)
+}
@@ -0,0 +1,270 @@
+package singleflight
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// NewCaller returns a Caller configured with the given options.
+//
+// The zero value of a Caller is ready to use and behaves like one returned by NewCaller with no options.
+func NewCaller[K comparable, V any](opts ...Option) *Caller[K, V] {
+	caller := new(Caller[K, V])
+	caller.configure(opts)
+
+	return caller
+}
+
+// configure configures the Caller, which must not have been used yet, with the given options.
+func (caller *Caller[K, V]) configure(opts []Option) {
+	for _, opt := range opts {
+		opt.apply(caller)
+	}
+
+	if caller.opts.capacity > 0 {
+		caller.calls = make(map[K]*call[K, V], caller.opts.capacity)
+	}
+}
+
+// Option configures a Caller.
+type Option interface {
+	apply(caller configurable)
+}
+
+// configurable is implemented by every instantiation of Caller.
+type configurable interface {
+	options() *options
+}
+
+// options holds the configuration of a Caller which does not depend on its type parameters.
+type options struct {
+	linger            int
+	trackKeys         bool
+	durationSmoothing float64
+	timeout           time.Duration
+	admitDeadlines    bool
+	executor          func(task func())
+	ttl               time.Duration
+	ttlJitter         float64
+	identify          func(context.Context) string
+	workers           int
+	queueTimeout      time.Duration
+	stallTimeout      time.Duration
+	keepStale         bool
+	history           int
+	capacity          int
+	spinWait          time.Duration
+	maxAges           bool
+}
+
+func (caller *Caller[K, V]) options() *options {
+	return &caller.opts
+}
+
+// keyOptions holds the configuration of a Caller which depends on its key type alone.
+type keyOptions[K comparable] struct {
+	onEvict    func(K, EvictReason)
+	onComplete func(Execution[K])
+	onConsume  func(Consumption[K])
+	authorize  func(context.Context, K) error
+}
+
+func (caller *Caller[K, V]) keyOptions() *keyOptions[K] {
+	return &caller.keyOpts
+}
+
+// valueOptions holds the configuration of a Caller which depends on its value type alone.
+type valueOptions[V any] struct {
+	copy        func(V) V
+	maxHeldSize int
+	sizeOf      func(V) int
+}
+
+func (caller *Caller[K, V]) valueOptions() *valueOptions[V] {
+	return &caller.valueOpts
+}
+
+// typedOptions holds the configuration of a Caller which depends on both its type parameters.
+type typedOptions[K comparable, V any] struct {
+	ttlFor   func(K, V, error) time.Duration
+	pickPeer func(K) (Peer[K, V], bool)
+}
+
+// optionFunc implements Option for configuration which does not depend on the type parameters of a Caller.
+type optionFunc func(*options)
+
+func (fn optionFunc) apply(caller configurable) {
+	fn(caller.options())
+}
+
+// keyOption implements Option for configuration which depends on the key type of a Caller alone.
+type keyOption[K comparable] func(*keyOptions[K])
+
+func (fn keyOption[K]) apply(caller configurable) {
+	keyed, ok := caller.(interface{ keyOptions() *keyOptions[K] })
+	if !ok {
+		var key K
+		panic(fmt.Sprintf("singleflight: option for keys of type %T applied to a %T", key, caller))
+	}
+
+	fn(keyed.keyOptions())
+}
+
+// valueOption implements Option for configuration which depends on the value type of a Caller alone.
+type valueOption[V any] func(*valueOptions[V])
+
+func (fn valueOption[V]) apply(caller configurable) {
+	valued, ok := caller.(interface{ valueOptions() *valueOptions[V] })
+	if !ok {
+		var v V
+		panic(fmt.Sprintf("singleflight: option for values of type %T applied to a %T", v, caller))
+	}
+
+	fn(valued.valueOptions())
+}
+
+// typedOption implements Option for configuration which depends on both the type parameters of a Caller.
+type typedOption[K comparable, V any] func(*typedOptions[K, V])
+
+func (fn typedOption[K, V]) apply(caller configurable) {
+	typed, ok := caller.(*Caller[K, V])
+	if !ok {
+		panic(fmt.Sprintf("singleflight: option for a %T applied to a %T", typed, caller))
+	}
+
+	fn(&typed.typedOpts)
+}
+
+// WithLinger configures the number of callers which, arriving after a call has completed, will be served its
+// results without re-execution. The completed call is dropped once it has served them all, unless forgotten or
+// expired earlier.
+//
+// WithLinger may be combined with WithTTL or WithTTLFor, in which case completed calls stop serving late callers
+// once either limit is reached.
+func WithLinger(n int) Option {
+	return optionFunc(func(opts *options) {
+		opts.linger = n
+	})
+}
+
+// WithCapacity configures the number of keys the Caller expects to hold calls for at once, so that the map holding
+// them is sized accordingly upfront rather than repeatedly grown, and rehashed, during bursts of calls for new keys.
+// The map still grows past the given capacity as needed.
+//
+// WithCapacity panics in case n is negative.
+func WithCapacity(n int) Option {
+	if n < 0 {
+		panic(fmt.Sprintf("singleflight: invalid capacity %d", n))
+	}
+
+	return optionFunc(func(opts *options) {
+		opts.capacity = n
+	})
+}
+
+// WithKeyStats enables the collection of per-key statistics, as reported by KeyStats. Since these are retained
+// for every key the Caller has been called with, memory usage grows with the number of distinct keys.
+func WithKeyStats() Option {
+	return optionFunc(func(opts *options) {
+		opts.trackKeys = true
+	})
+}
+
+// WithDurationEstimates enables the per-key exponentially weighted moving average of execution durations, as
+// reported by EstimatedDuration, with the given smoothing factor. Higher factors discount older executions faster.
+//
+// WithDurationEstimates panics in case smoothing is not in the (0, 1] range.
+func WithDurationEstimates(smoothing float64) Option {
+	if smoothing <= 0 || smoothing > 1 {
+		panic(fmt.Sprintf("singleflight: invalid smoothing factor %v", smoothing))
+	}
+
+	return optionFunc(func(opts *options) {
+		opts.durationSmoothing = smoothing
+	})
+}
+
+// WithTimeout bounds the duration of every execution, unless overridden via CallWithTimeout.
+func WithTimeout(timeout time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.timeout = timeout
+	})
+}
+
+// WithDeadlineAdmission configures the Caller to fail callers which would otherwise attach to an in-flight call that,
+// based on its estimated duration, is unlikely to complete before their deadline, with ErrWouldMissDeadline.
+//
+// WithDeadlineAdmission has no effect unless durations are being estimated via WithDurationEstimates.
+func WithDeadlineAdmission() Option {
+	return optionFunc(func(opts *options) {
+		opts.admitDeadlines = true
+	})
+}
+
+// WithExecutor configures the Caller to run the tasks of CallAsync, being the executions it starts and the
+// callbacks it invokes, via the given executor instead of on goroutines of their own.
+//
+// The executor must eventually run every task it is given.
+func WithExecutor(executor func(task func())) Option {
+	return optionFunc(func(opts *options) {
+		opts.executor = executor
+	})
+}
+
+// WithTTL configures the duration for which completed calls serve the callers arriving after them with their
+// results, without re-execution. Expired calls are dropped once their TTL elapses, or upon being looked up past it,
+// whichever comes first.
+func WithTTL(ttl time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.ttl = ttl
+	})
+}
+
+// WithTTLFor is like WithTTL but the duration is determined per completed call, by fn, given its key and results.
+// Durations of zero or less denote calls which should not linger based on time.
+//
+// fn overrides the duration configured WithTTL.
+func WithTTLFor[K comparable, V any](fn func(key K, v V, err error) time.Duration) Option {
+	return typedOption[K, V](func(opts *typedOptions[K, V]) {
+		opts.ttlFor = fn
+	})
+}
+
+// WithTTLJitter configures the Caller to randomly adjust the duration for which each completed call lingers around,
+// as configured WithTTL or WithTTLFor, by up to the given fraction of it in either direction. A fraction of .1
+// spreads calls that would otherwise expire together over ±10% of their duration.
+//
+// WithTTLJitter panics in case fraction is not in the [0, 1) range.
+func WithTTLJitter(fraction float64) Option {
+	if fraction < 0 || fraction >= 1 {
+		panic(fmt.Sprintf("singleflight: invalid TTL jitter fraction %v", fraction))
+	}
+
+	return optionFunc(func(opts *options) {
+		opts.ttlJitter = fraction
+	})
+}
+
+// WithCopy configures the Caller to serve each caller with its own copy of the value of the call, as made by fn,
+// rather than the value itself. It guards callers sharing values of reference types, such as slices, maps or
+// pointers, from one another's mutations.
+//
+// The value the execution returned is never served, so that a caller may not mutate it before the rest get to copy
+// it.
+func WithCopy[V any](fn func(V) V) Option {
+	return valueOption[V](func(opts *valueOptions[V]) {
+		opts.copy = fn
+	})
+}
+
+// WithAuthorize configures the Caller to consult fn before letting any caller either start or attach to a call for
+// the given key. Callers fn returns an error for are served that error instead.
+//
+// fn is passed the context of each caller, so that it may base its decision on the identity of the caller it
+// carries. It is how callers of different tenants are kept from sharing the calls of one another.
+func WithAuthorize[K comparable](fn func(ctx context.Context, key K) error) Option {
+	return keyOption[K](func(opts *keyOptions[K]) {
+		opts.authorize = fn
+	})
+}
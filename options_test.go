@@ -0,0 +1,107 @@
+package singleflight
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewCaller(t *testing.T) {
+	t.Parallel()
+
+	caller := NewCaller[string, bool](
+		WithLinger(1),
+		WithKeyStats(),
+		WithDurationEstimates(.25),
+		WithTimeout(time.Second),
+		WithDeadlineAdmission(),
+	)
+
+	assertDeepEqual(t, caller.opts, options{
+		linger:            1,
+		trackKeys:         true,
+		durationSmoothing: .25,
+		timeout:           time.Second,
+		admitDeadlines:    true,
+	})
+
+	assertDeepEqual(t, NewCaller[string, bool]().opts, options{})
+}
+
+func TestWithCapacity(t *testing.T) {
+	t.Parallel()
+
+	caller := NewCaller[int, int](WithCapacity(2))
+	assertEqual(t, 2, caller.opts.capacity)
+	assertTrue(t, caller.calls != nil)
+
+	// the map grows past the given capacity
+	var wg sync.WaitGroup
+	release := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			v, err := caller.Call(context.Background(), i, func(context.Context) (int, error) {
+				<-release
+
+				return i, nil
+			})
+			assertNil(t, err)
+			assertEqual(t, i, v)
+		}(i)
+	}
+
+	for caller.running.Load() < 4 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	assertTrue(t, NewCaller[int, int]().calls == nil)
+	assertPanics(t, func() { _ = WithCapacity(-1) })
+}
+
+func TestWithDurationEstimatesPanics(t *testing.T) {
+	t.Parallel()
+
+	for _, smoothing := range []float64{-1, 0, 1.5} {
+		assertPanics(t, func() { _ = WithDurationEstimates(smoothing) })
+	}
+}
+
+func TestWithTTLJitterPanics(t *testing.T) {
+	t.Parallel()
+
+	for _, fraction := range []float64{-.1, 1, 2} {
+		assertPanics(t, func() { _ = WithTTLJitter(fraction) })
+	}
+}
+
+func assertPanics(t *testing.T, fn func()) {
+	t.Helper()
+
+	defer func() {
+		t.Helper()
+
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+
+	fn()
+}
+
+func assertDeepEqual[T any](t *testing.T, actual, expected T) {
+	t.Helper()
+
+	if reflect.DeepEqual(actual, expected) {
+		return
+	}
+
+	t.Errorf("expected %+v, got: %+v", expected, actual)
+}
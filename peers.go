@@ -0,0 +1,53 @@
+package singleflight
+
+import "context"
+
+// Peer is a remote party calls may be forwarded to, typically a Caller running in another process and reached over
+// a user-provided transport.
+type Peer[K comparable, V any] interface {
+	// Call returns the results of the call for the given key, as carried out by the peer.
+	Call(ctx context.Context, key K) (V, error)
+}
+
+// WithPeerPicker configures the Caller to forward the calls it starts for keys owned by peers, as determined by
+// pick, to them instead of executing fn. pick reports false for the keys the local process owns. Callers sharing a
+// forwarded call share the results the peer returned.
+//
+// Forwarded calls are bound by the Caller's timeout like the executions of fn are. Transports serving forwarded
+// calls should make them with a LocalContext so that peers disagreeing about ownership do not forward calls in
+// circles.
+func WithPeerPicker[K comparable, V any](pick func(key K) (Peer[K, V], bool)) Option {
+	return typedOption[K, V](func(opts *typedOptions[K, V]) {
+		opts.pickPeer = pick
+	})
+}
+
+type localContextKeyType struct{}
+
+// LocalContext returns a copy of ctx which makes the calls it's passed to be executed locally, regardless of the
+// peer owning their key.
+func LocalContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, localContextKeyType{}, true)
+}
+
+// forward returns fn, or a function forwarding the call for the given key to its owner, in case a peer owns it and
+// ctx is not a LocalContext.
+func (caller *Caller[K, V]) forward(
+	ctx context.Context,
+	key K,
+	fn func(context.Context) (V, error),
+) func(context.Context) (V, error) {
+	pick := caller.typedOpts.pickPeer
+	if pick == nil || ctx.Value(localContextKeyType{}) != nil {
+		return fn
+	}
+
+	peer, ok := pick(key)
+	if !ok {
+		return fn
+	}
+
+	return func(ctx context.Context) (V, error) {
+		return peer.Call(ctx, key)
+	}
+}
@@ -0,0 +1,63 @@
+package singleflight
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// node is a Peer forwarding calls to a Caller it owns, as a transport would.
+type node struct {
+	name       string
+	caller     *Caller[string, string]
+	executions int64
+}
+
+func (n *node) Call(ctx context.Context, key string) (string, error) {
+	return n.caller.Call(LocalContext(ctx), key, n.fn)
+}
+
+func (n *node) fn(ctx context.Context) (string, error) {
+	atomic.AddInt64(&n.executions, 1)
+
+	return n.name + ":" + n.caller.KeyFromContext(ctx), nil
+}
+
+func TestPeerPicker(t *testing.T) {
+	t.Parallel()
+
+	// keys starting with "a" are owned by a, the rest by b
+	a, b := &node{name: "a"}, &node{name: "b"}
+	owner := func(key string) *node {
+		if strings.HasPrefix(key, "a") {
+			return a
+		}
+
+		return b
+	}
+
+	for _, n := range []*node{a, b} {
+		n := n
+
+		n.caller = NewCaller[string, string](WithPeerPicker(func(key string) (Peer[string, string], bool) {
+			if o := owner(key); o != n {
+				return o, true
+			}
+
+			return nil, false
+		}))
+	}
+
+	for _, key := range []string{"a1", "b1", "a2"} {
+		for _, n := range []*node{a, b} {
+			got, err := n.caller.Call(context.Background(), key, n.fn)
+			assertNil(t, err)
+			assertEqual(t, got, owner(key).name+":"+key)
+		}
+	}
+
+	// every key has been executed by its owner alone, once per call
+	assertEqual(t, atomic.LoadInt64(&a.executions), 4)
+	assertEqual(t, atomic.LoadInt64(&b.executions), 2)
+}
@@ -0,0 +1,233 @@
+package singleflight
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WithWorkers configures the Caller to carry out executions on a pool of at most n worker goroutines it owns,
+// rather than on the goroutines of the callers starting them, so that the number of concurrent executions, and the
+// stack they use, is bounded and their scheduling does not depend on which caller arrived first. Executions started
+// while every worker is busy are queued until a worker becomes available, by the priority their starters' contexts
+// carry, as set via PriorityContext, and otherwise in the order they were started. Workers exit once the queue is
+// empty.
+//
+// Callers starting executions wait for their results like the callers attaching to them do, being bound by their
+// context. In case an execution panics, the caller which started it via Call, CallWithTimeout or CallWithInfo panics
+// like it would have, had it carried out the execution itself.
+//
+// WithWorkers panics in case n is negative. A pool of zero workers denotes no pool at all.
+func WithWorkers(n int) Option {
+	if n < 0 {
+		panic(fmt.Sprintf("singleflight: invalid number of workers %d", n))
+	}
+
+	return optionFunc(func(opts *options) {
+		opts.workers = n
+	})
+}
+
+// WithQueueTimeout bounds the duration executions may remain queued for on the pool of a Caller configured
+// WithWorkers. Executions which remain queued for longer are dropped, and the callers attached to them are served
+// ErrQueueTimeout, rather than having them wait for a worker indefinitely. A timeout of zero or less imposes no bound.
+func WithQueueTimeout(timeout time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.queueTimeout = timeout
+	})
+}
+
+type priorityContextKeyType struct{}
+
+// PriorityContext returns a copy of ctx carrying the given priority. Executions started via Callers configured
+// WithWorkers with contexts of higher priority are carried out before the queued executions of lower priority.
+// Executions of equal priority are carried out in the order they were started. The default priority is zero.
+func PriorityContext(ctx context.Context, priority int) context.Context {
+	return context.WithValue(ctx, priorityContextKeyType{}, priority)
+}
+
+// PriorityFromContext returns the priority ctx carries, as set via PriorityContext, or zero in case it carries none.
+func PriorityFromContext(ctx context.Context) int {
+	priority, _ := ctx.Value(priorityContextKeyType{}).(int)
+
+	return priority
+}
+
+// pool runs tasks on a bounded number of goroutines, queuing the tasks which exceed the bound by priority.
+type pool struct {
+	mu      sync.Mutex
+	running int       // number of workers running
+	queue   taskQueue // tasks waiting for a worker
+	seq     uint64    // sequence number of the latest queued task
+}
+
+// task is a task run on a pool.
+type task struct {
+	run      func()
+	expire   func() // invoked, instead of run, in case the task times out while queued
+	priority int
+	seq      uint64 // orders tasks of equal priority
+	index    int    // index of the task in the queue, or -1 in case it's not queued
+	timer    *time.Timer
+}
+
+// submit runs t on a worker of the pool, of at most size workers, once one is available. In case t is queued for
+// longer than the given timeout, if positive, it is expired instead.
+func (p *pool) submit(size int, timeout time.Duration, t *task) {
+	p.mu.Lock()
+	if p.running < size {
+		p.running++
+		p.mu.Unlock()
+
+		go p.work(t)
+
+		return
+	}
+
+	p.seq++
+	t.seq = p.seq
+	heap.Push(&p.queue, t)
+
+	if timeout > 0 {
+		t.timer = time.AfterFunc(timeout, func() { p.expire(t) })
+	}
+	p.mu.Unlock()
+}
+
+// expire expires t, in case it's still queued.
+func (p *pool) expire(t *task) {
+	p.mu.Lock()
+	if t.index < 0 {
+		// a worker picked the task up in the meantime
+		p.mu.Unlock()
+
+		return
+	}
+	heap.Remove(&p.queue, t.index)
+	p.mu.Unlock()
+
+	t.expire()
+}
+
+// work runs t and then the queued tasks, until none is left.
+func (p *pool) work(t *task) {
+	for {
+		t.run()
+
+		p.mu.Lock()
+		if p.queue.Len() == 0 {
+			p.running--
+			p.mu.Unlock()
+
+			return
+		}
+
+		t = heap.Pop(&p.queue).(*task) //nolint:forcetypeassert // the queue holds tasks
+		if t.timer != nil {
+			t.timer.Stop()
+		}
+		p.mu.Unlock()
+	}
+}
+
+// depth returns the number of tasks queued.
+func (p *pool) depth() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.queue.Len()
+}
+
+// taskQueue implements heap.Interface for tasks, ordering them by descending priority and ascending sequence.
+type taskQueue []*task
+
+func (q taskQueue) Len() int {
+	return len(q)
+}
+
+func (q taskQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+
+	return q[i].seq < q[j].seq
+}
+
+func (q taskQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *taskQueue) Push(x any) {
+	t := x.(*task) //nolint:forcetypeassert // the queue holds tasks
+	t.index = len(*q)
+	*q = append(*q, t)
+}
+
+func (q *taskQueue) Pop() any {
+	old := *q
+	n := len(old)
+
+	t := old[n-1]
+	old[n-1] = nil
+	t.index = -1
+	*q = old[:n-1]
+
+	return t
+}
+
+// pooled reports whether the Caller carries out executions on its pool.
+func (caller *Caller[K, V]) pooled() bool {
+	return caller.opts.workers > 0
+}
+
+// runPooled runs fn on behalf of the given call, which the caller ctx belongs to started, on the Caller's pool and
+// waits for its results. It panics, once the results have been served, in case fn panicked.
+//
+// The call must have a done channel.
+func (caller *Caller[K, V]) runPooled(
+	ctx context.Context,
+	call *call[K, V],
+	timeout time.Duration,
+	fn func(context.Context) (V, error),
+) (V, error) {
+	caller.submit(ctx, call, func() {
+		defer func() {
+			// run panics with the PanicError it serves in case fn panicked; the caller waiting for it re-panics
+			if r := recover(); r != nil {
+				if _, ok := r.(*PanicError); !ok {
+					panic(r)
+				}
+			}
+		}()
+
+		caller.run(ctx, call, timeout, fn)
+	})
+
+	v, err := caller.wait(ctx, call)
+	if panicked, ok := err.(*PanicError); ok { //nolint:errorlint // only the PanicError run serves is of interest
+		panic(panicked)
+	}
+
+	return v, err
+}
+
+// submit submits run, which runs the given call the caller ctx belongs to started, to the Caller's pool, at the
+// priority ctx carries. In case the call times out while queued, it is completed with ErrQueueTimeout instead.
+func (caller *Caller[K, V]) submit(ctx context.Context, call *call[K, V], run func()) {
+	caller.pool.submit(caller.opts.workers, caller.opts.queueTimeout, &task{
+		run: run,
+		expire: func() {
+			caller.mu.Lock()
+			caller.track(call.key, func(s *Stats) { s.QueueTimeouts++ })
+			caller.mu.Unlock()
+
+			var zero V
+			caller.complete(call, zero, ErrQueueTimeout, nil)
+		},
+		priority: PriorityFromContext(ctx),
+	})
+}
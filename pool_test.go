@@ -0,0 +1,228 @@
+package singleflight
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithWorkersBoundsExecutions(t *testing.T) {
+	t.Parallel()
+
+	const workers = 2
+
+	var (
+		caller           = NewCaller[string, string](WithWorkers(workers))
+		running, maxSeen atomic.Int32
+	)
+
+	fn := func(ctx context.Context) (string, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			seen := maxSeen.Load()
+			if n <= seen || maxSeen.CompareAndSwap(seen, n) {
+				break
+			}
+		}
+
+		time.Sleep(shortPause / 4)
+
+		return caller.KeyFromContext(ctx), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3*workers; i++ {
+		key := strconv.Itoa(i)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			v, err := caller.Call(context.Background(), key, fn)
+			assertNil(t, err)
+			assertEqual(t, v, key)
+		}()
+	}
+
+	// executions Begin and CallAsync start are carried out on the pool as well
+	f := caller.Begin(context.Background(), "begin", fn)
+
+	done := make(chan struct{})
+	caller.CallAsync(context.Background(), "async", fn, func(v string, err error) {
+		defer close(done)
+
+		assertNil(t, err)
+		assertEqual(t, v, "async")
+	})
+
+	wg.Wait()
+	<-done
+
+	v, err := f.Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, v, "begin")
+
+	assertEqual(t, maxSeen.Load(), int32(workers))
+}
+
+func TestWithWorkersPanics(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller  = NewCaller[string, int](WithWorkers(1))
+		entered = make(chan struct{})
+		release = make(chan struct{})
+
+		leaderPanicked = make(chan any, 1)
+	)
+
+	go func() {
+		defer func() { leaderPanicked <- recover() }()
+
+		_, _ = caller.Call(context.Background(), "key", func(context.Context) (int, error) {
+			close(entered)
+			<-release
+
+			panic("boom")
+		})
+	}()
+	<-entered
+
+	f := caller.Begin(context.Background(), "key", nil)
+	close(release)
+
+	_, err := f.Await(context.Background())
+	assertErrorIs(t, err, ErrPanicked)
+
+	panicked, ok := (<-leaderPanicked).(*PanicError)
+	assertTrue(t, ok)
+	assertEqual(t, panicked.Value, any("boom"))
+}
+
+func TestWithWorkersCanceledLeader(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller  = NewCaller[string, int](WithWorkers(1))
+		release = make(chan struct{})
+	)
+
+	f := caller.Begin(context.Background(), "other", func(context.Context) (int, error) {
+		<-release
+
+		return 0, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), shortPause)
+	defer cancel()
+
+	// the execution is queued behind the one for "other"; the leader stops waiting once its context is done
+	_, err := caller.Call(ctx, "key", func(ctx context.Context) (int, error) {
+		return 1, ctx.Err()
+	})
+	assertErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+
+	_, err = f.Await(context.Background())
+	assertNil(t, err)
+}
+
+func TestWithWorkersPanicsOnNegative(t *testing.T) {
+	t.Parallel()
+
+	assertPanics(t, func() { WithWorkers(-1) })
+}
+
+func TestWithWorkersPriority(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller  = NewCaller[string, int](WithWorkers(1))
+		release = make(chan struct{})
+
+		mu    sync.Mutex
+		order []string
+	)
+
+	blocker := caller.Begin(context.Background(), "blocker", func(context.Context) (int, error) {
+		<-release
+
+		return 0, nil
+	})
+
+	fn := func(ctx context.Context) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		order = append(order, caller.KeyFromContext(ctx))
+
+		return 0, nil
+	}
+
+	futures := []*Future[int]{
+		caller.Begin(context.Background(), "low", fn),
+		caller.Begin(PriorityContext(context.Background(), 1), "high", fn),
+		caller.Begin(context.Background(), "low2", fn),
+		caller.Begin(PriorityContext(context.Background(), -1), "lowest", fn),
+		caller.Begin(PriorityContext(context.Background(), 1), "high2", fn),
+	}
+	assertEqual(t, caller.Stats().Queued, uint64(len(futures)))
+
+	close(release)
+
+	for _, f := range append(futures, blocker) {
+		_, err := f.Await(context.Background())
+		assertNil(t, err)
+	}
+
+	assertDeepEqual(t, order, []string{"high", "high2", "low", "low2", "lowest"})
+	assertEqual(t, caller.Stats().Queued, uint64(0))
+}
+
+func TestWithQueueTimeout(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller = NewCaller[string, int](
+			WithWorkers(1),
+			WithQueueTimeout(shortPause),
+			WithKeyStats(),
+		)
+		release  = make(chan struct{})
+		executed atomic.Bool
+	)
+
+	blocker := caller.Begin(context.Background(), "blocker", func(context.Context) (int, error) {
+		<-release
+
+		return 0, nil
+	})
+
+	f := caller.Begin(context.Background(), "key", func(context.Context) (int, error) {
+		executed.Store(true)
+
+		return 1, nil
+	})
+
+	_, err := caller.Call(context.Background(), "key", nil)
+	assertErrorIs(t, err, ErrQueueTimeout)
+
+	_, err = f.Await(context.Background())
+	assertErrorIs(t, err, ErrQueueTimeout)
+
+	stats, _ := caller.KeyStats("key")
+	assertEqual(t, stats.QueueTimeouts, uint64(1))
+	assertEqual(t, caller.Stats().Queued, uint64(0))
+
+	close(release)
+
+	_, err = blocker.Await(context.Background())
+	assertNil(t, err)
+	assertFalse(t, executed.Load())
+}
@@ -0,0 +1,30 @@
+package singleflight
+
+import "context"
+
+// publisher is implemented by calls of values of type V.
+type publisher[V any] interface {
+	publish(v V, err error) bool
+}
+
+func (call *call[K, V]) publish(v V, err error) bool {
+	return call.caller.complete(call, v, err, nil)
+}
+
+// Publish completes the execution ctx belongs to, the innermost one in case executions are nested, with the given
+// results, serving them to the callers waiting for the call, while the function carrying out the execution keeps
+// running, such as in order to write the results back to a cache or emit metrics. It reports whether it did so, which
+// it does not in case ctx belongs to no execution, the execution is of values of another type or it has completed
+// already.
+//
+// The results the function returns once it has published its results are discarded, though it panicking still
+// crashes the caller which started the call, like it would have otherwise. Since functions are executed on the
+// goroutines of the callers starting them, unless the Caller is configured WithWorkers, those callers keep waiting
+// for them to return. Executions which published their results are reported by Stats as running until they return.
+func Publish[V any](ctx context.Context, v V, err error) bool {
+	if call, ok := ctx.Value(executionContextKey{}).(publisher[V]); ok {
+		return call.publish(v, err)
+	}
+
+	return false
+}
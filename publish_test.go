@@ -0,0 +1,97 @@
+package singleflight
+
+import (
+	"context"
+	"runtime"
+	"testing"
+)
+
+func TestPublish(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller    Caller[string, int]
+		entered   = make(chan struct{})
+		published = make(chan bool, 2)
+		release   = make(chan struct{})
+	)
+
+	leader := caller.Begin(context.Background(), "key", func(ctx context.Context) (int, error) {
+		<-entered
+
+		published <- Publish(ctx, 1, nil)
+		published <- Publish(ctx, 2, nil) // the call has completed already
+
+		<-release
+
+		return 3, errAssert
+	})
+
+	follower := caller.Begin(context.Background(), "key", nil)
+	close(entered)
+
+	// the callers are served the published results while the execution keeps running
+	v, err := follower.Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, v, 1)
+
+	v, err = leader.Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, v, 1)
+
+	assertTrue(t, <-published)
+	assertFalse(t, <-published)
+	assertEqual(t, caller.Stats().Running, uint64(1))
+
+	close(release)
+	for caller.Stats().Running != 0 {
+		runtime.Gosched()
+	}
+}
+
+func TestPublishLeader(t *testing.T) {
+	t.Parallel()
+
+	var caller Caller[string, int]
+
+	// the caller executing the function is served the published results once it returns
+	v, err := caller.Call(context.Background(), "key", func(ctx context.Context) (int, error) {
+		assertTrue(t, Publish(ctx, 1, nil))
+
+		return 2, nil
+	})
+	assertNil(t, err)
+	assertEqual(t, v, 1)
+}
+
+func TestPublishMismatch(t *testing.T) {
+	t.Parallel()
+
+	var caller Caller[string, int]
+
+	_, err := caller.Call(context.Background(), "key", func(ctx context.Context) (int, error) {
+		assertFalse(t, Publish(ctx, "1", nil))
+
+		return 1, nil
+	})
+	assertNil(t, err)
+
+	assertFalse(t, Publish(context.Background(), 1, nil))
+}
+
+func TestPublishThenPanic(t *testing.T) {
+	t.Parallel()
+
+	var caller Caller[string, int]
+
+	defer func() {
+		_, ok := recover().(*PanicError)
+		assertTrue(t, ok)
+	}()
+
+	_, _ = caller.Call(context.Background(), "key", func(ctx context.Context) (int, error) {
+		Publish(ctx, 1, nil)
+
+		panic("boom")
+	})
+}
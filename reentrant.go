@@ -0,0 +1,32 @@
+package singleflight
+
+import "context"
+
+// nested is implemented by calls.
+type nested interface {
+	parent() context.Context
+}
+
+// parent returns the context the execution of the call was derived from.
+func (call *call[K, V]) parent() context.Context {
+	return call.Context
+}
+
+// executing reports whether ctx belongs, directly or via the contexts of nested executions, to the execution of the
+// given call.
+func executing(ctx context.Context, target any) bool {
+	for {
+		switch call := ctx.Value(executionContextKey{}); {
+		case call == nil:
+			return false
+		case call == target:
+			return true
+		default:
+			n, ok := call.(nested)
+			if !ok {
+				return false
+			}
+			ctx = n.parent()
+		}
+	}
+}
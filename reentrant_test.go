@@ -0,0 +1,75 @@
+package singleflight
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReentrantCall(t *testing.T) {
+	t.Parallel()
+
+	var caller Caller[string, int]
+
+	v, err := caller.Call(context.Background(), "key", func(ctx context.Context) (int, error) {
+		_, err := caller.Call(ctx, "key", func(context.Context) (int, error) { return 2, nil })
+
+		var reentrant *ReentrantCallError
+		assertTrue(t, errors.As(err, &reentrant))
+		assertErrorIs(t, err, ErrReentrantCall)
+		assertEqual(t, any("key"), reentrant.Key)
+
+		id, _ := CallIDFromContext(ctx)
+		assertEqual(t, id, reentrant.ID)
+		assertTrue(t, bytes.Contains(reentrant.Stack, []byte("TestReentrantCall")))
+		assertEqual(t, len(reentrant.Origin) == 0, strings.Contains(err.Error(), "-tags singleflightdebug"))
+
+		_, err = caller.TryCall(ctx, "key")
+		assertErrorIs(t, err, ErrReentrantCall)
+
+		_, err = caller.Begin(ctx, "key", nil).Await(context.Background())
+		assertErrorIs(t, err, ErrReentrantCall)
+
+		return 1, nil
+	})
+	assertNil(t, err)
+	assertEqual(t, 1, v)
+}
+
+func TestTransitiveReentrantCall(t *testing.T) {
+	t.Parallel()
+
+	var (
+		a Caller[string, int]
+		b Caller[string, int]
+	)
+
+	_, err := a.Call(context.Background(), "key", func(ctx context.Context) (int, error) {
+		errs := make(chan error, 1)
+
+		// the execution calls into b for the same key, whose execution calls back into a on another goroutine
+		go func() {
+			_, err := b.Call(ctx, "key", func(ctx context.Context) (int, error) {
+				return a.Call(ctx, "key", nil)
+			})
+			errs <- err
+		}()
+
+		return 0, <-errs
+	})
+	assertErrorIs(t, err, ErrReentrantCall)
+}
+
+func TestNestedCallsForOtherKeys(t *testing.T) {
+	t.Parallel()
+
+	var caller Caller[string, int]
+
+	v, err := caller.Call(context.Background(), "outer", func(ctx context.Context) (int, error) {
+		return caller.Call(ctx, "inner", func(context.Context) (int, error) { return 1, nil })
+	})
+	assertNil(t, err)
+	assertEqual(t, 1, v)
+}
@@ -0,0 +1,92 @@
+package singleflight
+
+import (
+	"slices"
+	"sync"
+)
+
+// Inspectable is implemented by every instantiation of Caller, and may be implemented by the wrappers users build
+// around them, so that they may be registered via Register regardless of their type parameters.
+type Inspectable interface {
+	Stats() Stats
+}
+
+// Recorder is implemented by every instantiation of Caller. The administration handlers built on top of the registry
+// serve the histories of the registered Callers which implement it, as configured WithHistory.
+type Recorder interface {
+	AnyHistory() []Execution[any]
+}
+
+var registry struct {
+	mu      sync.RWMutex
+	callers map[string]Inspectable
+}
+
+// Register registers the given Caller under the given name, so that its statistics are aggregated along with the
+// ones of every other registered Caller and served by the administration handlers and metrics exporters built on top
+// of the registry. Register panics in case a Caller is already registered under the name.
+func Register(name string, caller Inspectable) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if _, ok := registry.callers[name]; ok {
+		panic("singleflight: Caller " + name + " registered twice")
+	}
+
+	if registry.callers == nil {
+		registry.callers = make(map[string]Inspectable)
+	}
+	registry.callers[name] = caller
+}
+
+// Unregister removes the Caller registered under the given name, in case there is one.
+func Unregister(name string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	delete(registry.callers, name)
+}
+
+// Registered returns the names of the registered Callers, in order.
+func Registered() []string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	names := make([]string, 0, len(registry.callers))
+	for name := range registry.callers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// Lookup returns the Caller registered under the given name. It reports false in case there is none.
+func Lookup(name string) (Inspectable, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	caller, ok := registry.callers[name]
+
+	return caller, ok
+}
+
+// RegisteredStats returns the statistics of each registered Caller, by name, along with their sum.
+func RegisteredStats() (stats map[string]Stats, total Stats) {
+	registry.mu.RLock()
+	callers := make(map[string]Inspectable, len(registry.callers))
+	for name, caller := range registry.callers {
+		callers[name] = caller
+	}
+	registry.mu.RUnlock()
+
+	stats = make(map[string]Stats, len(callers))
+	for name, caller := range callers {
+		s := caller.Stats()
+
+		stats[name] = s
+		total.add(s)
+	}
+
+	return stats, total
+}
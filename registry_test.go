@@ -0,0 +1,52 @@
+package singleflight
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	var (
+		a Caller[string, int]
+		b Caller[int, string]
+	)
+
+	Register("registry-a", &a)
+	defer Unregister("registry-a")
+
+	Register("registry-b", &b)
+	defer Unregister("registry-b")
+
+	assertPanics(t, func() { Register("registry-a", &b) })
+
+	got, ok := Lookup("registry-b")
+	assertTrue(t, ok)
+	assertTrue(t, got == Inspectable(&b))
+
+	names := Registered()
+	assertTrue(t, slices.Contains(names, "registry-a") && slices.Contains(names, "registry-b"))
+
+	release := make(chan struct{})
+	f := a.Begin(context.Background(), "key", func(context.Context) (int, error) {
+		<-release
+
+		return 1, nil
+	})
+	_ = a.Begin(context.Background(), "key", nil)
+	close(release)
+
+	_, err := f.Await(context.Background())
+	assertNil(t, err)
+
+	stats, total := RegisteredStats()
+	assertEqual(t, stats["registry-a"], Stats{Followers: 1})
+	assertEqual(t, stats["registry-b"], Stats{})
+	assertTrue(t, total.Followers >= 1)
+
+	Unregister("registry-b")
+	_, ok = Lookup("registry-b")
+	assertFalse(t, ok)
+}
@@ -0,0 +1,100 @@
+package singleflight
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Schedule describes the times at which scheduled actions, such as the ones ForgetOn carries out, take place.
+type Schedule interface {
+	// Next returns the first time of the Schedule after the given one, or the zero time in case there is none.
+	Next(after time.Time) time.Time
+}
+
+// ScheduleFunc adapts a function to the Schedule interface.
+type ScheduleFunc func(after time.Time) time.Time
+
+// Next implements Schedule.
+func (fn ScheduleFunc) Next(after time.Time) time.Time {
+	return fn(after)
+}
+
+// Every returns a Schedule of the times aligned to multiples of the given interval since the zero time, such as the
+// start of every hour for an interval of an hour. It panics in case interval is not positive.
+func Every(interval time.Duration) Schedule {
+	if interval <= 0 {
+		panic("singleflight: non-positive schedule interval")
+	}
+
+	return ScheduleFunc(func(after time.Time) time.Time {
+		return after.Truncate(interval).Add(interval)
+	})
+}
+
+// Daily returns a Schedule of the times of each day, in the given location, which are the given offset, such as
+// 6*time.Hour, past midnight. It panics in case offset is negative or not shorter than a day.
+func Daily(offset time.Duration, loc *time.Location) Schedule {
+	if offset < 0 || offset >= 24*time.Hour {
+		panic("singleflight: daily schedule offset out of range")
+	}
+
+	return ScheduleFunc(func(after time.Time) time.Time {
+		after = after.In(loc)
+
+		y, m, d := after.Date()
+		for day := d; ; day++ {
+			midnight := time.Date(y, m, day, 0, 0, 0, 0, loc)
+			if next := midnight.Add(offset); next.After(after) {
+				return next
+			}
+		}
+	})
+}
+
+// At returns a Schedule of the given times.
+func At(times ...time.Time) Schedule {
+	times = slices.Clone(times)
+	slices.SortFunc(times, func(a, b time.Time) int { return a.Compare(b) })
+
+	return ScheduleFunc(func(after time.Time) time.Time {
+		for _, t := range times {
+			if t.After(after) {
+				return t
+			}
+		}
+
+		return time.Time{}
+	})
+}
+
+// ForgetOn forgets, at each time of the given schedule, the calls for the keys match reports true for, like
+// ForgetFunc does, so that the results of calls for data which changes on a known cadence are not served past the
+// changes. A nil match forgets all calls, like Purge does.
+//
+// ForgetOn blocks until ctx is done, in which case it returns its cause, or the schedule has no more times, in which
+// case it returns nil.
+func (caller *Caller[K, V]) ForgetOn(ctx context.Context, schedule Schedule, match func(key K) bool) error {
+	for now := time.Now(); ; now = time.Now() {
+		next := schedule.Next(now)
+		if next.IsZero() {
+			return nil
+		}
+
+		timer := time.NewTimer(next.Sub(now))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return context.Cause(ctx)
+		case <-timer.C:
+		}
+
+		if match == nil {
+			caller.Purge()
+		} else {
+			caller.ForgetFunc(match)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEvery(t *testing.T) {
+	t.Parallel()
+
+	s := Every(time.Hour)
+
+	at := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+	assertEqual(t, s.Next(at), time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC))
+	assertEqual(t, s.Next(at.Add(30*time.Minute)), time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	assertPanics(t, func() { Every(0) })
+}
+
+func TestDaily(t *testing.T) {
+	t.Parallel()
+
+	s := Daily(6*time.Hour, time.UTC)
+
+	assertEqual(t, s.Next(time.Date(2024, 1, 31, 5, 0, 0, 0, time.UTC)), time.Date(2024, 1, 31, 6, 0, 0, 0, time.UTC))
+	assertEqual(t, s.Next(time.Date(2024, 1, 31, 6, 0, 0, 0, time.UTC)), time.Date(2024, 2, 1, 6, 0, 0, 0, time.UTC))
+
+	assertPanics(t, func() { Daily(-time.Hour, time.UTC) })
+	assertPanics(t, func() { Daily(24*time.Hour, time.UTC) })
+}
+
+func TestAt(t *testing.T) {
+	t.Parallel()
+
+	var (
+		t1 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		t2 = t1.Add(time.Hour)
+		s  = At(t2, t1)
+	)
+
+	assertEqual(t, s.Next(t1.Add(-time.Second)), t1)
+	assertEqual(t, s.Next(t1), t2)
+	assertTrue(t, s.Next(t2).IsZero())
+}
+
+func TestForgetOn(t *testing.T) {
+	t.Parallel()
+
+	caller := NewCaller[string, int](WithLinger(10))
+
+	for _, key := range []string{"a", "b"} {
+		_, err := caller.Call(context.Background(), key, func(context.Context) (int, error) { return 1, nil })
+		assertNil(t, err)
+	}
+
+	at := time.Now().Add(shortPause)
+	err := caller.ForgetOn(context.Background(), At(at), func(key string) bool { return key == "a" })
+	assertNil(t, err)
+	assertFalse(t, time.Now().Before(at))
+
+	_, err = caller.TryCall(context.Background(), "a")
+	assertErrorIs(t, err, ErrNotInFlight)
+
+	_, err = caller.TryCall(context.Background(), "b")
+	assertNil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), shortPause)
+	defer cancel()
+
+	err = caller.ForgetOn(ctx, Every(time.Hour), nil)
+	assertTrue(t, errors.Is(err, context.DeadlineExceeded))
+}
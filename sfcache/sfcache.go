@@ -0,0 +1,79 @@
+// Package sfcache adapts the singleflight package to the get-or-load contract of in-memory caches, such as ristretto
+// or otter, so that concurrent misses for the same key share a single load.
+package sfcache
+
+import (
+	"context"
+
+	"github.com/azazeal/singleflight"
+)
+
+// Loader loads the values of keys missing from a cache, sharing the loads of concurrent misses for the same key, and
+// stores them in the cache.
+//
+// The cache is accessed via functions, so that caches of any API may be adapted; for instance, given a ristretto
+// cache:
+//
+//	loader := sfcache.NewLoader(cache.Get, func(key string, v *User) { cache.Set(key, v, 1) }, loadUser)
+//
+// Load, which shares loads without accessing the cache, satisfies the loader interfaces of caches which load missing
+// values themselves.
+type Loader[K comparable, V any] struct {
+	caller *singleflight.Caller[K, V]
+	get    func(key K) (V, bool)
+	set    func(key K, v V)
+	load   func(ctx context.Context, key K) (V, error)
+}
+
+// NewLoader returns a Loader looking up values via get, loading missing ones via load and storing the values it loads
+// via set. set may be nil, in which case loaded values are not stored. The Caller sharing the loads is configured by
+// the given options.
+func NewLoader[K comparable, V any](
+	get func(key K) (V, bool),
+	set func(key K, v V),
+	load func(ctx context.Context, key K) (V, error),
+	opts ...singleflight.Option,
+) *Loader[K, V] {
+	return &Loader[K, V]{
+		caller: singleflight.NewCaller[K, V](opts...),
+		get:    get,
+		set:    set,
+		load:   load,
+	}
+}
+
+// Get returns the cached value for key, loading and caching it in case it's missing. Concurrent misses for the same
+// key share a single load.
+//
+// Values are looked up again once a load is shared, so that misses racing with the completion of a load for the
+// same key are served the value it stored rather than loading it anew.
+func (l *Loader[K, V]) Get(ctx context.Context, key K) (V, error) {
+	if v, ok := l.get(key); ok {
+		return v, nil
+	}
+
+	return l.caller.Call(ctx, key, func(ctx context.Context) (V, error) {
+		if v, ok := l.get(key); ok {
+			return v, nil
+		}
+
+		v, err := l.load(ctx, key)
+		if err == nil && l.set != nil {
+			l.set(key, v)
+		}
+
+		return v, err
+	})
+}
+
+// Load loads the value for key, sharing the load with concurrent loads for the same key, without accessing the cache.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	return l.caller.Call(ctx, key, func(ctx context.Context) (V, error) {
+		return l.load(ctx, key)
+	})
+}
+
+// Caller returns the Caller the Loader shares loads via, for inspection and for forgetting loads taking place.
+func (l *Loader[K, V]) Caller() *singleflight.Caller[K, V] {
+	return l.caller
+}
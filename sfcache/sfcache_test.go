@@ -0,0 +1,106 @@
+package sfcache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cache is a minimal cache.
+type cache struct {
+	mu sync.Mutex
+	m  map[string]int
+}
+
+func (c *cache) Get(key string) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.m[key]
+
+	return v, ok
+}
+
+func (c *cache) Set(key string, v int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.m == nil {
+		c.m = make(map[string]int)
+	}
+	c.m[key] = v
+}
+
+var errLoad = errors.New("load failed")
+
+func TestLoader(t *testing.T) {
+	t.Parallel()
+
+	var (
+		c     cache
+		loads atomic.Int32
+	)
+
+	loader := NewLoader(c.Get, c.Set, func(_ context.Context, key string) (int, error) {
+		loads.Add(1)
+		time.Sleep(50 * time.Millisecond)
+
+		if key == "missing" {
+			return 0, errLoad
+		}
+
+		return len(key), nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if v, err := loader.Get(context.Background(), "key"); v != 3 || err != nil {
+				t.Errorf("unexpected result: %d, %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := loads.Load(); got != 1 {
+		t.Fatalf("expected 1 load; got %d", got)
+	}
+
+	if v, ok := c.Get("key"); v != 3 || !ok {
+		t.Fatalf("expected the loaded value to be cached; got %d, %t", v, ok)
+	}
+
+	// cached values are not loaded again
+	if v, err := loader.Get(context.Background(), "key"); v != 3 || err != nil {
+		t.Fatalf("unexpected result: %d, %v", v, err)
+	}
+
+	if got := loads.Load(); got != 1 {
+		t.Fatalf("expected 1 load; got %d", got)
+	}
+
+	// failed loads are not cached
+	if _, err := loader.Get(context.Background(), "missing"); !errors.Is(err, errLoad) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := c.Get("missing"); ok {
+		t.Fatal("expected the failed load not to be cached")
+	}
+
+	// Load bypasses the cache
+	if v, err := loader.Load(context.Background(), "key"); v != 3 || err != nil {
+		t.Fatalf("unexpected result: %d, %v", v, err)
+	}
+
+	if got := loads.Load(); got != 3 {
+		t.Fatalf("expected 3 loads; got %d", got)
+	}
+}
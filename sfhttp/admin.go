@@ -0,0 +1,134 @@
+package sfhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/azazeal/singleflight"
+)
+
+// stats is the JSON representation of singleflight.Stats.
+type stats struct {
+	Followers     uint64 `json:"followers"`
+	Abandoned     uint64 `json:"abandoned"`
+	QueueTimeouts uint64 `json:"queue_timeouts"`
+	Queued        uint64 `json:"queued"`
+	Running       uint64 `json:"running"`
+	Oversized     uint64 `json:"oversized"`
+}
+
+func statsOf(s singleflight.Stats) stats {
+	return stats{
+		Followers:     s.Followers,
+		Abandoned:     s.Abandoned,
+		QueueTimeouts: s.QueueTimeouts,
+		Queued:        s.Queued,
+		Running:       s.Running,
+		Oversized:     s.Oversized,
+	}
+}
+
+// execution is the JSON representation of singleflight.Execution.
+type execution struct {
+	Key       string    `json:"key"`
+	ID        uint64    `json:"id"`
+	Started   time.Time `json:"started"`
+	Duration  string    `json:"duration"`
+	Error     string    `json:"error,omitempty"`
+	Followers int       `json:"followers"`
+	Consumers []string  `json:"consumers,omitempty"`
+}
+
+func historyOf(r singleflight.Recorder) []execution {
+	history := r.AnyHistory()
+
+	executions := make([]execution, len(history))
+	for i, exec := range history {
+		executions[i] = execution{
+			Key:       fmt.Sprint(exec.Key),
+			ID:        uint64(exec.ID),
+			Started:   exec.Started,
+			Duration:  exec.Duration.String(),
+			Followers: exec.Followers,
+			Consumers: exec.Consumers,
+		}
+
+		if exec.Err != nil {
+			executions[i].Error = exec.Err.Error()
+		}
+	}
+
+	return executions
+}
+
+// AdminHandler returns a handler serving, as JSON, the statistics of the Callers registered via
+// singleflight.Register, by name, along with their sum, as well as the histories of the ones configured
+// singleflight.WithHistory. It only serves GET and HEAD requests.
+func AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !readOnly(w, r) {
+			return
+		}
+
+		registered, total := singleflight.RegisteredStats()
+
+		body := struct {
+			Callers map[string]stats       `json:"callers"`
+			Total   stats                  `json:"total"`
+			History map[string][]execution `json:"history,omitempty"`
+		}{
+			Callers: make(map[string]stats, len(registered)),
+			Total:   statsOf(total),
+		}
+
+		for name, s := range registered {
+			body.Callers[name] = statsOf(s)
+
+			caller, ok := singleflight.Lookup(name)
+			if !ok {
+				continue
+			}
+
+			if recorder, ok := caller.(singleflight.Recorder); ok {
+				if history := historyOf(recorder); len(history) > 0 {
+					if body.History == nil {
+						body.History = make(map[string][]execution)
+					}
+					body.History[name] = history
+				}
+			}
+		}
+
+		serveJSON(w, &body)
+	})
+}
+
+// HistoryHandler returns a handler serving, as JSON, the history of the given Caller, as configured
+// singleflight.WithHistory, oldest execution first. It only serves GET and HEAD requests.
+func HistoryHandler(caller singleflight.Recorder) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if readOnly(w, r) {
+			serveJSON(w, historyOf(caller))
+		}
+	})
+}
+
+// readOnly responds with http.StatusMethodNotAllowed to requests other than GET and HEAD ones and reports whether r
+// is one.
+func readOnly(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+
+	w.Header().Set("Allow", "GET, HEAD")
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+	return false
+}
+
+func serveJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
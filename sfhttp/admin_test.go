@@ -0,0 +1,118 @@
+package sfhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/azazeal/singleflight"
+)
+
+func TestAdminHandler(t *testing.T) {
+	t.Parallel()
+
+	var (
+		a = singleflight.NewCaller[string, int](singleflight.WithHistory(4))
+		b = new(singleflight.Caller[int, string])
+	)
+
+	singleflight.Register("sfhttp-a", a)
+	defer singleflight.Unregister("sfhttp-a")
+
+	singleflight.Register("sfhttp-b", b)
+	defer singleflight.Unregister("sfhttp-b")
+
+	release := make(chan struct{})
+	f := a.Begin(context.Background(), "key", func(context.Context) (int, error) {
+		<-release
+
+		return 1, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		_ = a.Begin(context.Background(), "key", nil)
+	}
+	close(release)
+
+	if _, err := f.Await(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+
+	var body struct {
+		Callers map[string]stats       `json:"callers"`
+		Total   stats                  `json:"total"`
+		History map[string][]execution `json:"history"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := body.Callers["sfhttp-a"].Followers; got != 2 {
+		t.Errorf("expected 2 followers for a; got %d", got)
+	}
+
+	if _, ok := body.Callers["sfhttp-b"]; !ok {
+		t.Error("expected b to be reported")
+	}
+
+	if history := body.History["sfhttp-a"]; len(history) != 1 || history[0].Key != "key" || history[0].Followers != 2 {
+		t.Errorf("unexpected history for a: %+v", history)
+	}
+
+	if _, ok := body.History["sfhttp-b"]; ok {
+		t.Error("expected no history for b")
+	}
+
+	if body.Total.Followers < 2 {
+		t.Errorf("expected at least 2 followers in total; got %d", body.Total.Followers)
+	}
+
+	rec = httptest.NewRecorder()
+	AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+}
+
+func TestHistoryHandler(t *testing.T) {
+	t.Parallel()
+
+	caller := singleflight.NewCaller[int, int](singleflight.WithHistory(2))
+	for i := 0; i < 3; i++ {
+		_, _ = caller.Call(context.Background(), i, func(context.Context) (int, error) { return 0, errors.New("failed") })
+	}
+
+	rec := httptest.NewRecorder()
+	HistoryHandler(caller).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+
+	var history []execution
+	if err := json.NewDecoder(rec.Body).Decode(&history); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(history) != 2 {
+		t.Fatalf("expected 2 executions; got %d", len(history))
+	}
+
+	for i, exec := range history {
+		if want := strconv.Itoa(i + 1); exec.Key != want || exec.Error != "failed" {
+			t.Errorf("unexpected execution %d: %+v", i, exec)
+		}
+	}
+}
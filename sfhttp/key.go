@@ -0,0 +1,140 @@
+// Package sfhttp implements net/http integrations of the singleflight package.
+package sfhttp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// MaxBodySize is the size of the largest request bodies RequestKey reads.
+const MaxBodySize = 1 << 20
+
+// ErrBodyTooLarge is returned by RequestKey for requests whose bodies are larger than MaxBodySize.
+var ErrBodyTooLarge = errors.New("sfhttp: request body too large")
+
+// RequestKey returns a key identifying r by its method, its normalized URL, the values of the named headers and the
+// contents of its body, suitable for sharing calls made on behalf of equivalent requests.
+//
+// URLs are normalized by lowercasing their scheme and host, dropping default ports, fragments and user information
+// and sorting their query parameters by name. Header names are matched case-insensitively and neither the order in
+// which they are given nor their repetition affects the key.
+//
+// RequestKey consumes the body of r, replacing it with an equivalent one so that r remains usable. It fails with
+// ErrBodyTooLarge for bodies larger than MaxBodySize, which it reads no further than that, and leaves r usable as
+// well.
+func RequestKey(r *http.Request, headers ...string) (string, error) {
+	h := sha256.New()
+
+	writeField(h, r.Method)
+	writeField(h, normalizeURL(r))
+
+	names := make([]string, 0, len(headers))
+	for _, name := range headers {
+		names = append(names, http.CanonicalHeaderKey(name))
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	for _, name := range names {
+		values := r.Header.Values(name)
+
+		writeField(h, name)
+		writeLength(h, len(values))
+		for _, v := range values {
+			writeField(h, v)
+		}
+	}
+
+	if err := writeBody(h, r); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func normalizeURL(r *http.Request) string {
+	u := *r.URL
+	u.User = nil
+	u.Fragment, u.RawFragment = "", ""
+	u.RawQuery = u.Query().Encode()
+
+	if u.Host == "" {
+		// server requests carry their host separately and imply their scheme
+		u.Host = r.Host
+		if u.Scheme == "" {
+			u.Scheme = "http"
+			if r.TLS != nil {
+				u.Scheme = "https"
+			}
+		}
+	}
+	u.Scheme, u.Host = strings.ToLower(u.Scheme), strings.ToLower(u.Host)
+
+	if host, port, err := net.SplitHostPort(u.Host); err == nil {
+		if (port == "80" && u.Scheme == "http") || (port == "443" && u.Scheme == "https") {
+			u.Host = host
+			if strings.Contains(host, ":") {
+				// IPv6 literals keep their brackets
+				u.Host = "[" + host + "]"
+			}
+		}
+	}
+
+	return u.String()
+}
+
+func writeBody(h hash.Hash, r *http.Request) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		writeLength(h, 0)
+
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxBodySize+1))
+	if err == nil && len(body) > MaxBodySize {
+		// leave the body, read and unread, to the caller
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+		return ErrBodyTooLarge
+	}
+
+	if closeErr := r.Body.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	writeLength(h, len(body))
+	_, _ = h.Write(body)
+
+	return nil
+}
+
+// writeField writes s to h prefixed by its length so that distinct sequences of fields never hash alike.
+func writeField(h hash.Hash, s string) {
+	writeLength(h, len(s))
+	_, _ = io.WriteString(h, s)
+}
+
+func writeLength(h hash.Hash, n int) {
+	var buf [binary.MaxVarintLen64]byte
+	_, _ = h.Write(buf[:binary.PutUvarint(buf[:], uint64(n))])
+}
@@ -0,0 +1,119 @@
+package sfhttp
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestKey(t *testing.T) {
+	t.Parallel()
+
+	newRequest := func(method, url, body string, header ...string) *http.Request {
+		var r io.Reader
+		if body != "" {
+			r = strings.NewReader(body)
+		}
+
+		req, err := http.NewRequest(method, url, r) //nolint:noctx // not sent
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Add(header[i], header[i+1])
+		}
+
+		return req
+	}
+
+	key := func(req *http.Request, headers ...string) string {
+		t.Helper()
+
+		key, err := RequestKey(req, headers...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return key
+	}
+
+	base := key(newRequest(http.MethodGet, "http://example.com/a?x=1&y=2", ""), "Accept")
+
+	equivalent := []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/a?x=1&y=2", nil),
+		newRequest(http.MethodGet, "HTTP://EXAMPLE.com:80/a?y=2&x=1", ""),
+		newRequest(http.MethodGet, "http://user@example.com/a?x=1&y=2#fragment", ""),
+		newRequest(http.MethodGet, "http://example.com/a?x=1&y=2", "", "Other", "ignored"),
+	}
+	for _, req := range equivalent {
+		if got := key(req, "accept", "Accept"); got != base {
+			t.Errorf("expected %s to be keyed like the base request", req.URL)
+		}
+	}
+
+	distinct := []*http.Request{
+		newRequest(http.MethodHead, "http://example.com/a?x=1&y=2", ""),
+		newRequest(http.MethodGet, "http://example.com/b?x=1&y=2", ""),
+		newRequest(http.MethodGet, "http://example.com/a?x=1&y=3", ""),
+		newRequest(http.MethodGet, "https://example.com/a?x=1&y=2", ""),
+		newRequest(http.MethodGet, "http://example.com/a?x=1&y=2", "", "Accept", "text/plain"),
+		newRequest(http.MethodGet, "http://example.com/a?x=1&y=2", "body"),
+	}
+	for _, req := range distinct {
+		if got := key(req, "Accept"); got == base {
+			t.Errorf("expected %s %s to be keyed differently than the base request", req.Method, req.URL)
+		}
+	}
+}
+
+func TestRequestKeyPreservesBody(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/", strings.NewReader("body"))
+
+	k1, err := RequestKey(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k2, err := RequestKey(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if k1 != k2 {
+		t.Error("expected keying a request to be repeatable")
+	}
+
+	if body, _ := io.ReadAll(req.Body); string(body) != "body" {
+		t.Errorf("expected the body to be preserved, got %q", body)
+	}
+}
+
+func TestRequestKeyIPv6(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "http://[::1]:80/", nil)
+	if got := normalizeURL(req); got != "http://[::1]/" {
+		t.Errorf("unexpected URL: %s", got)
+	}
+}
+
+func TestRequestKeyBodyTooLarge(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("a", MaxBodySize+2)
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/", strings.NewReader(body))
+
+	if _, err := RequestKey(req); !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, _ := io.ReadAll(req.Body); string(got) != body {
+		t.Errorf("expected the body to be preserved, got %d bytes", len(got))
+	}
+}
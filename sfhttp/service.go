@@ -0,0 +1,176 @@
+package sfhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/azazeal/singleflight"
+)
+
+// Handler returns a handler serving the shared results of the calls caller makes to fn, encoded via codec, so that
+// other processes, such as sidecars or programs written in other languages, may share them as well. It serves GET
+// and HEAD requests carrying the key of the call in the key query parameter, such as:
+//
+//	GET /?key=user:1
+//
+// Successful calls are served with http.StatusOK and the encoding of their value as the body. Calls fn fails are
+// served with http.StatusInternalServerError and the message of the error fn returned as the body. Calls which fail
+// otherwise, such as the ones which panic, are served with generic messages, so that stacks and other details meant
+// for the process itself do not reach its clients: canceled, timed out and stalled calls, as well as the ones of
+// closed Callers, with http.StatusServiceUnavailable and the rest with http.StatusInternalServerError. Requests
+// carrying no key are rejected with http.StatusBadRequest.
+//
+// Calls are made with a singleflight.LocalContext, so that the handler may serve the peers of a Caller configured
+// singleflight.WithPeerPicker via Clients.
+func Handler[V any](
+	caller *singleflight.Caller[string, V],
+	fn func(ctx context.Context, key string) (V, error),
+	codec singleflight.Codec[V],
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !readOnly(w, r) {
+			return
+		}
+
+		key := r.URL.Query().Get("key")
+		if key == "" {
+			http.Error(w, "sfhttp: missing key", http.StatusBadRequest)
+
+			return
+		}
+
+		v, err := call(caller, singleflight.LocalContext(r.Context()), key, fn)
+		if err != nil {
+			code, msg := failure(err)
+			http.Error(w, msg, code)
+
+			return
+		}
+
+		data, err := codec.Marshal(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("sfhttp: failed encoding value: %v", err), http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(data)
+	})
+}
+
+// call is like caller.CallWithKey but returns, rather than panics with, the PanicError of executions which panic.
+func call[V any](
+	caller *singleflight.Caller[string, V],
+	ctx context.Context,
+	key string,
+	fn func(ctx context.Context, key string) (V, error),
+) (v V, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			pe, ok := r.(*singleflight.PanicError)
+			if !ok {
+				panic(r)
+			}
+
+			err = pe
+		}
+	}()
+
+	return caller.CallWithKey(ctx, key, fn)
+}
+
+// failure returns the status code and the message Handlers serve the given error with.
+func failure(err error) (int, string) {
+	switch {
+	case errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, singleflight.ErrQueueTimeout),
+		errors.Is(err, singleflight.ErrLeaderStalled),
+		errors.Is(err, singleflight.ErrClosed):
+		return http.StatusServiceUnavailable, "sfhttp: call not completed"
+	case errors.Is(err, singleflight.ErrPanicked),
+		errors.Is(err, singleflight.ErrReentrantCall),
+		errors.Is(err, singleflight.ErrUncomparableKey):
+		return http.StatusInternalServerError, "sfhttp: call failed"
+	default:
+		return http.StatusInternalServerError, err.Error()
+	}
+}
+
+// Client calls the Handler served at URL. It implements singleflight.Peer so that Callers may forward calls to
+// the processes serving Handlers.
+type Client[V any] struct {
+	// URL is the URL of the Handler.
+	URL string
+
+	// Codec decodes the values the Handler serves. It must match the one the Handler encodes them with.
+	Codec singleflight.Codec[V]
+
+	// HTTPClient makes the requests. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Call returns the results of the call for the given key, as served by the Handler. Failures the Handler reports are
+// returned as StatusErrors.
+func (c *Client[V]) Call(ctx context.Context, key string) (V, error) {
+	var zero V
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return zero, err
+	}
+
+	query := u.Query()
+	query.Set("key", key)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return zero, err
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return zero, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return zero, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return zero, &StatusError{
+			Code:    resp.StatusCode,
+			Message: strings.TrimSpace(string(body)),
+		}
+	}
+
+	return c.Codec.Unmarshal(body)
+}
+
+// StatusError is the error Clients return for the failures Handlers report.
+type StatusError struct {
+	// Code is the HTTP status code the Handler responded with.
+	Code int
+
+	// Message is the message the Handler responded with, such as the message of the error of a failed call.
+	Message string
+}
+
+// Error implements error for StatusError.
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("sfhttp: %d %s: %s", err.Code, http.StatusText(err.Code), err.Message)
+}
@@ -0,0 +1,145 @@
+package sfhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/azazeal/singleflight"
+)
+
+var _ singleflight.Peer[string, int] = (*Client[int])(nil)
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller     singleflight.Caller[string, int]
+		executions atomic.Int32
+		release    = make(chan struct{})
+	)
+
+	fn := func(_ context.Context, key string) (int, error) {
+		executions.Add(1)
+		<-release
+
+		if key == "fail" {
+			return 0, errors.New("failed")
+		}
+
+		return len(key), nil
+	}
+
+	srv := httptest.NewServer(Handler(&caller, fn, singleflight.JSONCodec[int]{}))
+	defer srv.Close()
+
+	client := &Client[int]{
+		URL:   srv.URL,
+		Codec: singleflight.JSONCodec[int]{},
+	}
+
+	const callers = 4
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			v, err := client.Call(context.Background(), "a key")
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if v != len("a key") {
+				t.Errorf("unexpected value: %d", v)
+			}
+		}()
+	}
+
+	for caller.Stats().Followers < callers-1 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if got := executions.Load(); got != 1 {
+		t.Errorf("expected 1 execution; got %d", got)
+	}
+
+	_, err := client.Call(context.Background(), "fail")
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if statusErr.Code != http.StatusInternalServerError || statusErr.Message != "failed" {
+		t.Errorf("unexpected error: %+v", statusErr)
+	}
+}
+
+func TestHandlerRejectsInvalidRequests(t *testing.T) {
+	t.Parallel()
+
+	var caller singleflight.Caller[string, int]
+	handler := Handler(&caller, func(context.Context, string) (int, error) { return 0, nil }, singleflight.GobCodec[int]{})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unexpected status: %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/?key=key", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("unexpected status: %d", rec.Code)
+	}
+}
+
+func TestHandlerHidesInternalFailures(t *testing.T) {
+	t.Parallel()
+
+	var caller singleflight.Caller[string, int]
+
+	fn := func(ctx context.Context, key string) (int, error) {
+		switch key {
+		case "panic":
+			panic("secret")
+		case "timeout":
+			return 0, context.DeadlineExceeded
+		default:
+			return 0, errors.New(key)
+		}
+	}
+
+	handler := Handler(&caller, fn, singleflight.GobCodec[int]{})
+
+	cases := []struct {
+		key  string
+		code int
+		body string
+	}{
+		{"panic", http.StatusInternalServerError, "sfhttp: call failed"},
+		{"timeout", http.StatusServiceUnavailable, "sfhttp: call not completed"},
+		{"failed", http.StatusInternalServerError, "failed"},
+	}
+
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?key="+c.key, nil))
+
+		if rec.Code != c.code {
+			t.Errorf("%s: unexpected status: %d", c.key, rec.Code)
+		}
+		if body := strings.TrimSpace(rec.Body.String()); body != c.body {
+			t.Errorf("%s: unexpected body: %q", c.key, body)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Package sfqueue implements the deduplication of message deliveries for the consumers of message queues, such as
+// Kafka, SQS or NATS, on top of the singleflight package.
+package sfqueue
+
+import (
+	"context"
+
+	"github.com/azazeal/singleflight"
+)
+
+// Consumer processes messages of type M so that concurrent deliveries of the same message, as identified by their
+// message or deduplication IDs, share a single processing. The decision to acknowledge each delivery derives from the
+// result of the processing it shared.
+//
+// A Consumer is safe for concurrent use by the worker goroutines of a consumer.
+type Consumer[M any] struct {
+	caller  *singleflight.Caller[string, struct{}]
+	id      func(M) string
+	process func(context.Context, M) error
+}
+
+// NewConsumer returns a Consumer processing messages via process. Deliveries of messages id maps to the same ID share
+// their processing. The Caller sharing the processing is configured by the given options; WithLinger or WithTTL make
+// redeliveries which arrive shortly after a processing completed share it as well.
+func NewConsumer[M any](
+	id func(M) string,
+	process func(context.Context, M) error,
+	opts ...singleflight.Option,
+) *Consumer[M] {
+	return &Consumer[M]{
+		caller:  singleflight.NewCaller[string, struct{}](opts...),
+		id:      id,
+		process: process,
+	}
+}
+
+// Consume processes msg, unless a delivery of the same message is being processed, in which case it waits for the
+// latter to complete. It reports whether the delivery should be acknowledged, which it should in case the processing
+// it shared succeeded, along with the error the processing failed with, if any.
+//
+// A delivery whose ctx is done before the processing it shares completes should not be acknowledged; the error
+// Consume returns for it wraps the cause of ctx.
+func (c *Consumer[M]) Consume(ctx context.Context, msg M) (ack bool, err error) {
+	_, err = c.caller.Call(ctx, c.id(msg), func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, c.process(ctx, msg)
+	})
+
+	return err == nil, err
+}
+
+// Caller returns the Caller the Consumer shares processing via, for inspection.
+func (c *Consumer[M]) Caller() *singleflight.Caller[string, struct{}] {
+	return c.caller
+}
@@ -0,0 +1,109 @@
+package sfqueue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/azazeal/singleflight"
+)
+
+type message struct {
+	id   string
+	fail bool
+}
+
+var errProcessing = errors.New("processing failed")
+
+func TestConsumer(t *testing.T) {
+	t.Parallel()
+
+	var processed atomic.Int32
+
+	c := NewConsumer(func(msg message) string { return msg.id }, func(_ context.Context, msg message) error {
+		processed.Add(1)
+		time.Sleep(50 * time.Millisecond)
+
+		if msg.fail {
+			return errProcessing
+		}
+
+		return nil
+	}, singleflight.WithLinger(1))
+
+	const deliveries = 4
+
+	var (
+		wg    sync.WaitGroup
+		acks  atomic.Int32
+		nacks atomic.Int32
+	)
+
+	for _, msg := range []message{{id: "ok"}, {id: "failing", fail: true}} {
+		for i := 0; i < deliveries; i++ {
+			wg.Add(1)
+
+			go func(msg message) {
+				defer wg.Done()
+
+				switch ack, err := c.Consume(context.Background(), msg); {
+				case ack && err == nil:
+					acks.Add(1)
+				case !ack && errors.Is(err, errProcessing):
+					nacks.Add(1)
+				default:
+					t.Errorf("unexpected result for %q: %t, %v", msg.id, ack, err)
+				}
+			}(msg)
+		}
+	}
+
+	wg.Wait()
+
+	if got := processed.Load(); got != 2 {
+		t.Errorf("expected 2 processings; got %d", got)
+	}
+
+	if acks.Load() != deliveries || nacks.Load() != deliveries {
+		t.Errorf("expected %d acks and nacks; got %d and %d", deliveries, acks.Load(), nacks.Load())
+	}
+}
+
+func TestConsumerCanceled(t *testing.T) {
+	t.Parallel()
+
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+
+	c := NewConsumer(func(msg message) string { return msg.id }, func(context.Context, message) error {
+		close(started)
+		<-release
+
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		if ack, err := c.Consume(context.Background(), message{id: "id"}); !ack || err != nil {
+			t.Errorf("unexpected result: %t, %v", ack, err)
+		}
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if ack, err := c.Consume(ctx, message{id: "id"}); ack || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected result: %t, %v", ack, err)
+	}
+
+	close(release)
+	<-done
+}
@@ -0,0 +1,287 @@
+// Package sftest implements reusable stress-test scenarios for singleflight Callers and for the wrappers users build
+// around them, meant to be run under the race detector.
+package sftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Target is the subset of the API of a singleflight.Caller the scenarios exercise.
+type Target[K comparable, V any] interface {
+	Call(ctx context.Context, key K, fn func(context.Context) (V, error)) (V, error)
+}
+
+// Forgetter is implemented by the Targets which may forget the calls for their keys.
+type Forgetter[K comparable] interface {
+	Forget(key K)
+}
+
+// Scenario configures the scenarios run against Targets.
+type Scenario[K comparable, V any] struct {
+	// Keys are the keys the scenarios call. They must not be empty.
+	Keys []K
+
+	// Value returns the value executions for the given key are to produce. It must not be nil.
+	Value func(key K) V
+
+	// Equal reports whether the given values are equal. It defaults to reflect.DeepEqual.
+	Equal func(a, b V) bool
+
+	// Callers is the number of concurrent callers per key. It defaults to 16.
+	Callers int
+
+	// Latency is the duration each execution takes. It defaults to 10ms.
+	Latency time.Duration
+
+	// Timeout bounds the duration of each scenario, past which the Target is deemed to be deadlocked. It defaults to
+	// 10s.
+	Timeout time.Duration
+}
+
+const (
+	defaultCallers = 16
+	defaultLatency = 10 * time.Millisecond
+	defaultTimeout = 10 * time.Second
+)
+
+// Run runs all scenarios against target, each one as a subtest. ForgetStorm is only run in case target implements
+// Forgetter.
+//
+// The scenarios share target. Targets which hold results past the completion of their calls should instead be passed
+// to the method of each scenario, each time anew.
+func (s *Scenario[K, V]) Run(t *testing.T, target Target[K, V]) {
+	t.Helper()
+
+	t.Run("Concurrent", func(t *testing.T) { s.Concurrent(t, target) })
+	t.Run("Cancellations", func(t *testing.T) { s.Cancellations(t, target) })
+	t.Run("Panics", func(t *testing.T) { s.Panics(t, target) })
+
+	if forgetter, ok := target.(Forgetter[K]); ok {
+		t.Run("ForgetStorm", func(t *testing.T) { s.ForgetStorm(t, target, forgetter) })
+	}
+}
+
+// Concurrent has concurrent callers call target for each key. It verifies that every caller is served the value for
+// its key and that no two executions for the same key overlap.
+func (s *Scenario[K, V]) Concurrent(tb testing.TB, target Target[K, V]) {
+	tb.Helper()
+
+	s.run(tb, true, func(key K, _ int, fn func(context.Context) (V, error)) {
+		v, err := target.Call(context.Background(), key, fn)
+		s.served(tb, key, v, err)
+	})
+}
+
+// Cancellations has concurrent callers call target for each key, while canceling the contexts of a random half of
+// them at random times. It verifies that callers whose contexts were not canceled are served the value for their key,
+// that callers whose contexts were canceled are served either that or an error wrapping context.Canceled, and that no
+// two executions for the same key overlap.
+func (s *Scenario[K, V]) Cancellations(tb testing.TB, target Target[K, V]) {
+	tb.Helper()
+
+	latency := s.latency()
+
+	s.run(tb, true, func(key K, i int, fn func(context.Context) (V, error)) {
+		if i%2 == 0 {
+			v, err := target.Call(context.Background(), key, fn)
+			s.served(tb, key, v, err)
+
+			return
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		timer := time.AfterFunc(time.Duration(rand.Int63n(int64(2*latency)+1)), cancel) //nolint:gosec // not sensitive
+		defer timer.Stop()
+
+		switch v, err := target.Call(ctx, key, fn); {
+		case errors.Is(err, context.Canceled):
+			break
+		case err != nil:
+			tb.Errorf("sftest: call for %v returned %v; expected nil or an error wrapping context.Canceled", key, err)
+		default:
+			s.served(tb, key, v, nil)
+		}
+	})
+}
+
+// Panics has concurrent callers call target for each key with functions which panic. It verifies that no caller is
+// served a value along with a nil error; each one must either panic or return an error.
+func (s *Scenario[K, V]) Panics(tb testing.TB, target Target[K, V]) {
+	tb.Helper()
+
+	s.run(tb, true, func(key K, _ int, fn func(context.Context) (V, error)) {
+		defer func() {
+			_ = recover()
+		}()
+
+		panicking := func(ctx context.Context) (V, error) {
+			_, _ = fn(ctx)
+
+			panic(fmt.Sprintf("sftest: panic for %v", key))
+		}
+
+		if _, err := target.Call(context.Background(), key, panicking); err == nil {
+			tb.Errorf("sftest: call for %v returned no error despite its execution panicking", key)
+		}
+	})
+}
+
+// ForgetStorm has concurrent callers call target for each key while other goroutines repeatedly forget the calls for
+// the keys via forgetter. It verifies that every caller is served the value for its key.
+func (s *Scenario[K, V]) ForgetStorm(tb testing.TB, target Target[K, V], forgetter Forgetter[K]) {
+	tb.Helper()
+
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for _, key := range s.keys(tb) {
+		wg.Add(1)
+
+		go func(key K) {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					forgetter.Forget(key)
+					time.Sleep(time.Millisecond)
+				}
+			}
+		}(key)
+	}
+
+	defer wg.Wait()
+	defer close(stop)
+
+	s.run(tb, false, func(key K, _ int, fn func(context.Context) (V, error)) {
+		v, err := target.Call(context.Background(), key, fn)
+		s.served(tb, key, v, err)
+	})
+}
+
+// run has s.Callers goroutines per key invoke call with the key, the index of the goroutine and the function it
+// should pass to target. In case exclusive is set, it verifies that no two executions of such functions for the same
+// key overlap.
+func (s *Scenario[K, V]) run(
+	tb testing.TB,
+	exclusive bool,
+	call func(key K, i int, fn func(context.Context) (V, error)),
+) {
+	tb.Helper()
+
+	var (
+		keys    = s.keys(tb)
+		callers = s.callers()
+		latency = s.latency()
+
+		start = make(chan struct{})
+		wg    sync.WaitGroup
+	)
+
+	for _, key := range keys {
+		key := key
+
+		var running atomic.Int32
+
+		fn := func(context.Context) (V, error) {
+			if n := running.Add(1); exclusive && n > 1 {
+				tb.Errorf("sftest: %d executions for %v overlap", n, key)
+			}
+			defer running.Add(-1)
+
+			time.Sleep(latency)
+
+			return s.Value(key), nil
+		}
+
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+
+			go func(key K, i int) {
+				defer wg.Done()
+
+				<-start
+				call(key, i, fn)
+			}(key, i)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		wg.Wait()
+	}()
+
+	close(start)
+
+	select {
+	case <-done:
+	case <-time.After(s.timeout()):
+		tb.Fatalf("sftest: callers did not return within %s", s.timeout())
+	}
+}
+
+// served verifies that the caller for the given key was served v, being the value for the key, and a nil error.
+func (s *Scenario[K, V]) served(tb testing.TB, key K, v V, err error) {
+	switch {
+	case err != nil:
+		tb.Errorf("sftest: call for %v returned %v", key, err)
+	case !s.equal(v, s.Value(key)):
+		tb.Errorf("sftest: call for %v returned %v; expected %v", key, v, s.Value(key))
+	}
+}
+
+func (s *Scenario[K, V]) keys(tb testing.TB) []K {
+	tb.Helper()
+
+	if len(s.Keys) == 0 || s.Value == nil {
+		tb.Fatal("sftest: Scenario.Keys and Scenario.Value must be set")
+	}
+
+	return s.Keys
+}
+
+func (s *Scenario[K, V]) equal(a, b V) bool {
+	if s.Equal != nil {
+		return s.Equal(a, b)
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func (s *Scenario[K, V]) callers() int {
+	if s.Callers > 0 {
+		return s.Callers
+	}
+
+	return defaultCallers
+}
+
+func (s *Scenario[K, V]) latency() time.Duration {
+	if s.Latency > 0 {
+		return s.Latency
+	}
+
+	return defaultLatency
+}
+
+func (s *Scenario[K, V]) timeout() time.Duration {
+	if s.Timeout > 0 {
+		return s.Timeout
+	}
+
+	return defaultTimeout
+}
@@ -0,0 +1,71 @@
+package sftest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/azazeal/singleflight"
+)
+
+func scenario() *Scenario[string, int] {
+	return &Scenario[string, int]{
+		Keys: []string{"1", "2", "3"},
+		Value: func(key string) int {
+			v, _ := strconv.Atoi(key)
+
+			return v
+		},
+	}
+}
+
+func TestCaller(t *testing.T) {
+	t.Parallel()
+
+	scenario().Run(t, new(singleflight.Caller[string, int]))
+}
+
+func TestCallerWithLinger(t *testing.T) {
+	t.Parallel()
+
+	s := scenario()
+	s.Concurrent(t, singleflight.NewCaller[string, int](singleflight.WithLinger(4)))
+
+	caller := singleflight.NewCaller[string, int](singleflight.WithLinger(4))
+	s.ForgetStorm(t, caller, caller)
+}
+
+// unshared is a Target which does not share calls.
+type unshared struct{}
+
+func (unshared) Call(ctx context.Context, _ string, fn func(context.Context) (int, error)) (int, error) {
+	return fn(ctx)
+}
+
+// recorder is a testing.TB recording the errors reported to it.
+type recorder struct {
+	testing.TB
+
+	mu     sync.Mutex
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestConcurrentDetectsOverlappingExecutions(t *testing.T) {
+	t.Parallel()
+
+	r := &recorder{TB: t}
+	scenario().Concurrent(r, unshared{})
+
+	if len(r.errors) == 0 {
+		t.Error("expected overlapping executions to be reported")
+	}
+}
@@ -0,0 +1,40 @@
+package singleflight
+
+import (
+	"context"
+	"fmt"
+)
+
+// NewCallerWithContext is like NewCaller but it binds the returned Caller to ctx, typically the context of the
+// application, so that shutting the application down shuts the Caller down as well: once ctx is done, the executions
+// taking place are canceled with its cause and subsequent calls fail with an error wrapping both ErrClosed and the
+// cause.
+func NewCallerWithContext[K comparable, V any](ctx context.Context, opts ...Option) *Caller[K, V] {
+	caller := NewCaller[K, V](opts...)
+	caller.parent = ctx
+
+	return caller
+}
+
+// closed returns the error calls to the Caller fail with, in case it is bound to a context which is done.
+func (caller *Caller[K, V]) closed() error {
+	if caller.parent == nil || caller.parent.Err() == nil {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %w", ErrClosed, context.Cause(caller.parent))
+}
+
+// bind returns a copy of ctx, the context of an execution, which is also canceled, with its cause, once the context
+// the Caller is bound to is done. The returned function releases the resources associated with the copy.
+func (caller *Caller[K, V]) bind(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(caller.parent, func() {
+		cancel(context.Cause(caller.parent))
+	})
+
+	return ctx, func() {
+		stop()
+		cancel(nil)
+	}
+}
@@ -0,0 +1,70 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNewCallerWithContext(t *testing.T) {
+	t.Parallel()
+
+	var (
+		errShutdown = errors.New("shutdown")
+		entered     = make(chan struct{})
+	)
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	caller := NewCallerWithContext[string, int](ctx, WithLinger(1))
+
+	v, err := caller.Call(context.Background(), "done", func(context.Context) (int, error) { return 1, nil })
+	assertNil(t, err)
+	assertEqual(t, 1, v)
+
+	f := caller.Begin(context.Background(), "key", func(ctx context.Context) (int, error) {
+		close(entered)
+		<-ctx.Done()
+
+		return 0, context.Cause(ctx)
+	})
+	<-entered
+
+	cancel(errShutdown)
+
+	// executions taking place are canceled with the cause
+	_, err = f.Await(context.Background())
+	assertErrorIs(t, err, errShutdown)
+
+	// and subsequent calls fail, lingering results notwithstanding
+	for _, key := range []string{"key", "done"} {
+		_, err = caller.Call(context.Background(), key, func(context.Context) (int, error) { return 2, nil })
+		assertErrorIs(t, err, ErrClosed)
+		assertErrorIs(t, err, errShutdown)
+	}
+
+	_, err = caller.TryCall(context.Background(), "done")
+	assertErrorIs(t, err, ErrClosed)
+}
+
+func TestNewCallerWithContextReleasesExecutions(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		caller = NewCallerWithContext[string, int](ctx)
+		inner  context.Context
+	)
+
+	_, err := caller.Call(context.Background(), "key", func(ctx context.Context) (int, error) {
+		inner = ctx
+
+		return 1, nil
+	})
+	assertNil(t, err)
+
+	// the contexts of completed executions are released
+	assertErrorIs(t, inner.Err(), context.Canceled)
+	assertNil(t, ctx.Err())
+}
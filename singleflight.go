@@ -3,79 +3,619 @@ package singleflight
 
 import (
 	"context"
+	"errors"
+	"math/rand"
+	"runtime/debug"
 	"sync"
-
-	"golang.org/x/sync/semaphore"
+	"sync/atomic"
+	"time"
 )
 
-// Caller wraps the functionality of the call sharing mechanism.
+// Caller wraps the functionality of the call sharing mechanism. Callers may be configured via NewCaller.
 //
 // A Caller must not be copied after first use.
 type Caller[K comparable, V any] struct {
+	opts      options
+	keyOpts   keyOptions[K]
+	valueOpts valueOptions[V]
+	typedOpts typedOptions[K, V]
+
 	mu    sync.Mutex
-	calls map[K]*call[V]
+	calls map[K]*call[K, V]
+	stats Stats
+	inv   callerInvariants // verified under the singleflightdebug build tag
+	keys  map[K]*keyState
+	stale map[K]staleValue[V]
+	pool  pool
+
+	history     []Execution[K] // ring buffer of the latest executions, when recorded
+	historyNext int            // index of the history slot to record the next execution in
+
+	running atomic.Int64 // number of executions taking place
+
+	parent context.Context //nolint:containedctx // the context the Caller is bound to, if any
 }
 
-const (
-	readerWeight = 1 << (30 * iota)
-	writerWeight
-)
+// call is a shared call. It doubles as the context its execution is carried out with, so that the common case of a
+// call no other caller attaches to costs a single allocation.
+type call[K comparable, V any] struct {
+	context.Context //nolint:containedctx // the call is the context of its own execution
 
-type call[V any] struct {
-	sem *semaphore.Weighted
+	caller *Caller[K, V]
+
+	key K
+	id  CallID
 	val V
 	err error
+
+	inv invariants // verified under the singleflightdebug build tag
+
+	// done is allocated by the first caller attaching to the call and closed once the call completes. It is
+	// guarded by the Caller's mutex.
+	done chan struct{}
+
+	beat atomic.Int64 // time of the latest heartbeat of the execution, in Unix nanoseconds, when stalls are detected
+
+	started time.Time // set only when the Caller needs it, as reported by timed
+
+	// completed, followers, waiters, remaining, expires and extra are guarded by the Caller's mutex.
+	completed bool
+	followers int         // number of callers which attached to the call while it took place
+	waiters   int         // number of callers attached to the call which have not stopped waiting for it
+	remaining int         // number of late callers the completed call may still serve, when counted
+	expires   time.Time   // the time the completed call stops serving late callers, when set
+	extra     *callExtras // allocated on first use, by the calls which need any of it
+}
+
+// callExtras holds the state of a call which only some Callers, or callers, make use of. It is kept apart from the
+// call so that the calls which need none of it remain small.
+//
+// callExtras are guarded by the Caller's mutex, apart from meta which is immutable once the call completes.
+type callExtras struct {
+	thresholds []threshold    // channels to close once waiters drops below their thresholds
+	callbacks  []func()       // invoked once the call completes
+	consumers  map[string]int // identities of the callers attached to the call, when identified, and their number
+	meta       map[string]any // metadata attached to the results of the call
+}
+
+// extras returns the extras of the call, allocating them in case it has none.
+//
+// The Caller's mutex must be held.
+func (call *call[K, V]) extras() *callExtras {
+	if call.extra == nil {
+		call.extra = new(callExtras)
+	}
+
+	return call.extra
 }
 
 // Call calls fn and returns the results. Concurrent callers sharing a key will also share the results of the first
 // call.
 //
-// fn may access the key passed to Call via KeyFromContext.
+// In case ctx is done before the results of the call are available, Call returns its cause, joined with the error of
+// the call in case the latter completed in the meantime.
+//
+// fn may access the key passed to Call via KeyFromContext; CallWithKey passes it to fn instead.
 func (caller *Caller[K, V]) Call(ctx context.Context, key K, fn func(context.Context) (V, error)) (V, error) {
+	v, _, err := caller.do(ctx, key, caller.opts.timeout, fn)
+
+	return v, err
+}
+
+// CallWithTimeout is like Call but, in case it ends up executing fn, it bounds the execution by the given timeout
+// instead of the Caller's. A timeout of zero or less imposes no bound.
+//
+// The timeout does not apply to waiting for the results of an in-flight call; ctx does.
+func (caller *Caller[K, V]) CallWithTimeout(
+	ctx context.Context,
+	key K,
+	timeout time.Duration,
+	fn func(context.Context) (V, error),
+) (V, error) {
+	v, _, err := caller.do(ctx, key, timeout, fn)
+
+	return v, err
+}
+
+// CallWithKey is like Call but fn is passed the key it's called for, sparing it from retrieving the key via
+// KeyFromContext.
+func (caller *Caller[K, V]) CallWithKey(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context, key K) (V, error),
+) (V, error) {
+	return caller.Call(ctx, key, func(ctx context.Context) (V, error) {
+		return fn(ctx, key)
+	})
+}
+
+// do implements Call. It additionally returns information on the call the caller was served by.
+func (caller *Caller[K, V]) do(
+	ctx context.Context,
+	key K,
+	timeout time.Duration,
+	fn func(context.Context) (V, error),
+) (V, CallInfo, error) {
+	call, leader, err := caller.join(ctx, key, true)
+	if err != nil {
+		var zero V
+		return zero, CallInfo{}, err
+	}
+
+	info := CallInfo{
+		ID:     call.id,
+		Leader: leader,
+	}
+
+	var v V
+	switch {
+	case leader && caller.pooled():
+		v, err = caller.runPooled(ctx, call, timeout, fn)
+	case leader:
+		caller.run(ctx, call, timeout, fn)
+
+		v, err = call.result()
+	default:
+		v, err = caller.wait(ctx, call)
+	}
+	info.Meta = call.metadata()
+
+	return v, info, err
+}
+
+// TryCall is like Call but it only attaches to a call already taking place, or lingering, for the given key. In case
+// there is none, TryCall returns ErrNotInFlight.
+func (caller *Caller[K, V]) TryCall(ctx context.Context, key K) (V, error) {
+	call, _, err := caller.join(ctx, key, false)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+
+	return caller.wait(ctx, call)
+}
+
+// wait waits for and returns the results of the given call, which join returned to a follower.
+func (caller *Caller[K, V]) wait(ctx context.Context, call *call[K, V]) (V, error) {
+	defer traceWait(ctx, call.key, call.id)()
+
+	if call.done == nil {
+		// the call had completed but lingered around
+		return call.result()
+	}
+
+	select {
+	case <-call.done:
+		// the call has completed; it may have lingered around, in which case the caller was never attached to it
+		return call.result()
+	default:
+	}
+
+	if caller.opts.spinWait > 0 && caller.spin(ctx, call) {
+		return call.result()
+	}
+
+	if caller.opts.stallTimeout > 0 {
+		return caller.watch(ctx, call)
+	}
+
+	select {
+	case <-call.done:
+		return call.result()
+	case <-ctx.Done():
+		caller.abandon(ctx, call)
+
+		var zero V
+		return zero, interrupted(ctx, call.done, call.result)
+	}
+}
+
+// abandon records that the caller ctx belongs to stopped waiting for the results of the given call.
+func (caller *Caller[K, V]) abandon(ctx context.Context, call *call[K, V]) {
+	call.leave(ctx, true)
+}
+
+// leave detaches the caller ctx belongs to, which joined the call with it, from the call, counting it as abandoned in
+// case it stopped waiting for the results of the call before they were available.
+func (call *call[K, V]) leave(ctx context.Context, abandoned bool) {
+	caller := call.caller
+	identity := caller.identify(ctx)
+
+	caller.mu.Lock()
+	defer caller.mu.Unlock()
+
+	if abandoned {
+		caller.track(call.key, func(s *Stats) { s.Abandoned++ })
+	}
+	call.detach(identity)
+}
+
+// interrupted returns the error for a wait on the results of a call, signaled via done, which ctx interrupted. The
+// error joins the cause of ctx with the error of the call, in case the call has completed by then.
+func interrupted[V any](ctx context.Context, done <-chan struct{}, result func() (V, error)) error {
+	cause := context.Cause(ctx)
+
+	select {
+	case <-done:
+		if _, err := result(); err != nil {
+			return errors.Join(cause, err)
+		}
+	default:
+	}
+
+	return cause
+}
+
+// join returns the call for the given key, starting one in case none exists and start is set, provided the caller is
+// authorized to. It reports whether the returned call was started and should therefore be run by the caller.
+//
+// Calls join does not start either have completed or have a done channel.
+func (caller *Caller[K, V]) join(ctx context.Context, key K, start bool) (_ *call[K, V], leader bool, _ error) {
+	if err := caller.closed(); err != nil {
+		return nil, false, err
+	}
+
+	if !hashable(key) {
+		return nil, false, ErrUncomparableKey
+	}
+
+	if authorize := caller.keyOpts.authorize; authorize != nil {
+		if err := authorize(ctx, key); err != nil {
+			return nil, false, err
+		}
+	}
+
+	identity := caller.identify(ctx)
+
 	caller.mu.Lock()
+	call, leader, evicted, err := caller.joinLocked(ctx, key, start)
+	late := err == nil && call.completed
+	if err == nil && !late {
+		call.attach(identity)
+	}
+	caller.mu.Unlock()
+
+	if evicted != 0 {
+		caller.evicted(key, evicted)
+	}
+
+	if fn := caller.keyOpts.onConsume; late && fn != nil {
+		fn(Consumption[K]{Key: key, ID: call.id, Identity: identity})
+	}
+
+	if reentrant, ok := err.(*ReentrantCallError); ok { //nolint:errorlint // joinLocked does not wrap it
+		reentrant.Stack = debug.Stack()
+	}
+
+	return call, leader, err
+}
 
+// joinLocked implements join. It additionally returns the reason it evicted the lingering call for the key, if it did.
+//
+// caller.mu must be held.
+func (caller *Caller[K, V]) joinLocked(
+	ctx context.Context,
+	key K,
+	start bool,
+) (_ *call[K, V], leader bool, evicted EvictReason, _ error) {
 	if caller.calls == nil {
-		caller.calls = make(map[K]*call[V])
+		caller.calls = make(map[K]*call[K, V], caller.opts.capacity)
 	}
 
-	// check whether an in-flight call exists for the key
-	if inflight, ok := caller.calls[key]; ok {
-		// an in-flight call exists; attach to it as a reader and return its result once available
-		caller.mu.Unlock()
+	// check whether a call exists for the key
+	inflight, ok := caller.calls[key]
+	if ok && inflight.completed && !inflight.expires.IsZero() && !time.Now().Before(inflight.expires) {
+		// the call has lingered around for long enough
+		delete(caller.calls, key)
+		inflight.inv.unmap(inflight.id)
+		ok, evicted = false, EvictExpired
+	}
+
+	if ok && start && !inflight.completed && tooOld(ctx, inflight.started) {
+		// the call is older than the caller tolerates; leave it to the callers attached to it and start a new one
+		delete(caller.calls, key)
+		inflight.inv.unmap(inflight.id)
+		ok, evicted = false, EvictSuperseded
+	}
+
+	if ok {
+		if inflight.completed {
+			// the call has completed but lingers around; serve its results, counting them down in case the call
+			// serves a limited number of late callers, as restored calls may regardless of the Caller's linger
+			if inflight.remaining > 0 {
+				inflight.remaining--
+				inflight.inv.serve(inflight.id, inflight.remaining)
 
-		if err := inflight.sem.Acquire(ctx, readerWeight); err != nil {
-			var zero V
-			return zero, err
+				if inflight.remaining == 0 {
+					delete(caller.calls, key)
+					inflight.inv.unmap(inflight.id)
+					evicted = EvictExhausted
+				}
+			}
+
+			return inflight, false, evicted, nil
+		}
+
+		if executing(ctx, inflight) {
+			// the caller belongs to the execution of the call; it would wait for itself forever
+			return nil, false, 0, &ReentrantCallError{
+				Key:    key,
+				ID:     inflight.id,
+				Origin: inflight.inv.origin(),
+			}
 		}
-		defer inflight.sem.Release(readerWeight)
 
-		return inflight.val, inflight.err
+		if caller.opts.admitDeadlines && caller.wouldMissDeadline(ctx, key, inflight) {
+			return nil, false, 0, ErrWouldMissDeadline
+		}
+
+		// an in-flight call exists; attach to it
+		if inflight.done == nil {
+			inflight.done = make(chan struct{})
+		}
+		caller.track(key, func(s *Stats) { s.Followers++ })
+		inflight.followers++
+
+		return inflight, false, 0, nil
+	}
+
+	// there's no in-flight call; start one, if we should
+	if !start {
+		return nil, false, evicted, ErrNotInFlight
 	}
 
-	// there's no in-flight call; start one
-	call := &call[V]{
-		sem: semaphore.NewWeighted(writerWeight),
+	call := &call[K, V]{
+		caller: caller,
+		key:    key,
+		id:     nextCallID(),
+	}
+
+	if caller.timed() {
+		call.started = time.Now()
+	}
+
+	if caller.pooled() {
+		// the caller starting the call waits for its results, like the callers attaching to it do
+		call.done = make(chan struct{})
 	}
-	_ = call.sem.Acquire(context.Background(), writerWeight) //nolint:contextcheck // guaranteed to succeed
 
 	caller.calls[key] = call
-	caller.mu.Unlock()
+	caller.inv.start(call.id)
+	call.inv.begin()
 
-	call.val, call.err = fn(context.WithValue(ctx, contextKeyType[K]{}, key))
+	return call, true, evicted, nil
+}
+
+// run executes fn on behalf of the given call it then completes. The execution is traced as a runtime/trace task
+// of its own, when the execution tracer is enabled.
+func (caller *Caller[K, V]) run(
+	ctx context.Context,
+	call *call[K, V],
+	timeout time.Duration,
+	fn func(context.Context) (V, error),
+) {
+	if caller.opts.stallTimeout > 0 {
+		call.heartbeat()
+	}
+
+	ctx, end := traceExecution(ctx, call.key, call.id)
+	defer end()
+
+	caller.running.Add(1)
+	v, err, panicked := call.execute(ctx, timeout, caller.forward(ctx, call.key, fn))
+	caller.running.Add(-1)
+
+	if !caller.complete(call, v, err, panicked) && panicked != nil {
+		// the execution panicked after publishing its results; crash like fn would have
+		panic(panicked)
+	}
+}
+
+// complete completes the given call with the given results, serving them to the callers attached to it, and reports
+// whether it did so. It reports false in case the call had completed already, which is the case for executions which
+// published their results via Publish.
+//
+// In case the execution of the call panicked, complete panics with the given PanicError once the results have been
+// served.
+func (caller *Caller[K, V]) complete(call *call[K, V], v V, err error, panicked *PanicError) bool {
+	ttl := caller.ttl(call.key, v, err)
+	oversized := caller.oversized(v, ttl)
+
+	// the call has finished; unless it completed already, we can mark it as completed and, unless
+	// it should linger, as no longer taking place by deleting it from the map, in case it has not
+	// been forgotten already
+	var took time.Duration
+	if !call.started.IsZero() {
+		took = time.Since(call.started)
+	}
 
-	// the call has finished; we're still the only active caller so we can mark
-	// this call as no longer taking place by deleting it from the map
 	caller.mu.Lock()
-	call.sem.Release(writerWeight)
-	delete(caller.calls, key)
+	if call.completed {
+		caller.mu.Unlock()
+
+		return false
+	}
+	call.val, call.err = v, err
+	if call.done != nil {
+		close(call.done)
+	}
+	if caller.opts.durationSmoothing > 0 {
+		caller.estimate(call.key, took)
+	}
+	if oversized {
+		caller.track(call.key, func(s *Stats) { s.Oversized++ })
+	} else if caller.opts.keepStale && call.err == nil {
+		caller.keep(call)
+	}
+	call.inv.complete(call.id)
+	call.completed = true
+	if caller.calls[call.key] == call && (oversized || !call.hold(caller.opts.linger, ttl)) {
+		delete(caller.calls, call.key)
+		call.inv.unmap(call.id)
+	}
+	var callbacks []func()
+	if extra := call.extra; extra != nil {
+		callbacks = extra.callbacks
+		extra.callbacks, extra.thresholds = nil, nil
+	}
+	var exec Execution[K]
+	if caller.keyOpts.onComplete != nil || caller.opts.history > 0 {
+		exec = Execution[K]{
+			Key:       call.key,
+			ID:        call.id,
+			Started:   call.started,
+			Duration:  took,
+			Err:       call.err,
+			Followers: call.followers,
+			Consumers: call.identities(),
+		}
+
+		if caller.opts.history > 0 {
+			caller.record(exec)
+		}
+	}
 	caller.mu.Unlock()
 
+	if fn := caller.keyOpts.onComplete; fn != nil {
+		fn(exec)
+	}
+
+	for _, callback := range callbacks {
+		callback()
+	}
+
+	if panicked != nil {
+		// the callers attached to the call have been served; crash like fn would have
+		panic(panicked)
+	}
+
+	return true
+}
+
+// ttl returns the duration a call for the given key which completed with the given results should linger around for,
+// jittered when configured so.
+func (caller *Caller[K, V]) ttl(key K, v V, err error) time.Duration {
+	ttl := caller.opts.ttl
+	if fn := caller.typedOpts.ttlFor; fn != nil {
+		ttl = fn(key, v, err)
+	}
+
+	if jitter := caller.opts.ttlJitter; jitter > 0 && ttl > 0 {
+		ttl += time.Duration(jitter * (2*rand.Float64() - 1) * float64(ttl)) //nolint:gosec // not security sensitive
+	}
+
+	return ttl
+}
+
+// hold prepares the completed call to serve the given number of late callers, if any, for the given duration, if
+// any, and reports whether it should linger around at all. Calls held for a duration are swept once it elapses.
+//
+// The Caller's mutex must be held.
+func (call *call[K, V]) hold(linger int, ttl time.Duration) bool {
+	call.remaining = linger
+	if ttl > 0 {
+		call.expires = time.Now().Add(ttl)
+		call.caller.sweepAfter(call, ttl)
+	}
+
+	return linger > 0 || ttl > 0
+}
+
+// timed reports whether the Caller records the times its calls start at, which it only does when configured in a way
+// which needs them.
+func (caller *Caller[K, V]) timed() bool {
+	return caller.opts.durationSmoothing > 0 ||
+		caller.keyOpts.onComplete != nil ||
+		caller.opts.history > 0 ||
+		caller.opts.spinWait > 0 ||
+		caller.opts.maxAges
+}
+
+// wouldMissDeadline reports whether the given in-flight call for the given key is estimated to complete after the
+// deadline of ctx.
+//
+// caller.mu must be held.
+func (caller *Caller[K, V]) wouldMissDeadline(ctx context.Context, key K, inflight *call[K, V]) bool {
+	deadline, ok := ctx.Deadline()
+	if !ok || inflight.started.IsZero() {
+		return false
+	}
+
+	state, ok := caller.keys[key]
+	if !ok || !state.estimated {
+		return false
+	}
+
+	return inflight.started.Add(state.estimate).After(deadline)
+}
+
+// result returns the results of the completed call, as they should be served to a caller.
+func (call *call[K, V]) result() (V, error) {
+	if fn := call.caller.valueOpts.copy; fn != nil {
+		return fn(call.val), call.err
+	}
+
 	return call.val, call.err
 }
 
-type contextKeyType[K comparable] struct{}
+// execute executes fn on behalf of the call, with the call serving as the context derived from ctx. In case fn
+// panics, execute recovers and returns the panic as an error.
+func (call *call[K, V]) execute(
+	ctx context.Context,
+	timeout time.Duration,
+	fn func(context.Context) (V, error),
+) (v V, err error, panicked *PanicError) { //nolint:revive // the panic is reported separately from the error
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if call.caller.parent != nil {
+		var release func()
+		ctx, release = call.caller.bind(ctx)
+		defer release()
+	}
+	call.Context = ctx
+
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = &PanicError{
+				Value: r,
+				Stack: debug.Stack(),
+			}
+			err = panicked
+		}
+	}()
+
+	v, err = fn(call)
+
+	return v, err, nil
+}
+
+// Value implements context.Context for the context of the call's execution, which carries its key as well as the
+// call itself.
+func (call *call[K, V]) Value(key any) any {
+	switch key := key.(type) {
+	case contextKey[K, V]:
+		if key.caller == call.caller {
+			return call.key
+		}
+	case executionContextKey:
+		return call
+	}
+
+	return call.Context.Value(key)
+}
+
+// contextKey is the key the contexts of executions carry their key under. It is scoped to the Caller of the execution
+// so that, when executions are nested, each Caller finds the key of its own.
+type contextKey[K comparable, V any] struct {
+	caller *Caller[K, V]
+}
 
-// KeyFromContext returns the key ctx carries. It panics in case ctx carries no key.
-func (*Caller[K, V]) KeyFromContext(ctx context.Context) K {
-	return ctx.Value(contextKeyType[K]{}).(K)
+// KeyFromContext returns the key ctx carries for the Caller, the one of its innermost execution ctx belongs to. It
+// panics in case ctx belongs to no execution of the Caller.
+func (caller *Caller[K, V]) KeyFromContext(ctx context.Context) K {
+	return ctx.Value(contextKey[K, V]{caller}).(K)
 }
@@ -3,6 +3,9 @@ package singleflight
 import (
 	"context"
 	"errors"
+	"runtime"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -72,6 +75,34 @@ func Test(t *testing.T) {
 	assertEqual(t, executions, 2)
 }
 
+func TestCallWithKey(t *testing.T) {
+	t.Parallel()
+
+	var caller Caller[int, string]
+
+	v, err := caller.CallWithKey(context.Background(), 42, func(_ context.Context, key int) (string, error) {
+		return strconv.Itoa(key), errAssert
+	})
+	assertError(t, err)
+	assertEqual(t, "42", v)
+}
+
+func TestKeyFromNestedContexts(t *testing.T) {
+	t.Parallel()
+
+	var outer, inner Caller[string, string]
+
+	v, err := outer.Call(context.Background(), "outer", func(ctx context.Context) (string, error) {
+		return inner.Call(ctx, "inner", func(ctx context.Context) (string, error) {
+			assertPanics(t, func() { _ = new(Caller[string, string]).KeyFromContext(ctx) })
+
+			return outer.KeyFromContext(ctx) + "/" + inner.KeyFromContext(ctx), nil
+		})
+	})
+	assertNil(t, err)
+	assertEqual(t, "outer/inner", v)
+}
+
 func TestSecondaryContextCancellation(t *testing.T) {
 	t.Parallel()
 
@@ -133,6 +164,344 @@ func TestSecondaryContextCancellation(t *testing.T) {
 	assertErrorIs(t, err3, context.DeadlineExceeded)
 }
 
+func TestLinger(t *testing.T) {
+	t.Parallel()
+
+	const key = "key"
+
+	var (
+		caller     = NewCaller[string, int64](WithLinger(2))
+		executions int64
+	)
+
+	fn := func(context.Context) (int64, error) {
+		return atomic.AddInt64(&executions, 1), nil
+	}
+
+	for _, exp := range []int64{1, 1, 1, 2, 2, 2, 3} {
+		got, err := caller.Call(context.Background(), key, fn)
+
+		assertNil(t, err)
+		assertEqual(t, got, exp)
+	}
+}
+
+func TestLingerServesDoneContexts(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller  = NewCaller[string, int](WithLinger(1000))
+		release = make(chan struct{})
+	)
+
+	// a follower makes the call allocate its done channel
+	f := caller.Begin(context.Background(), "key", func(context.Context) (int, error) {
+		<-release
+
+		return 1, nil
+	})
+	_ = caller.Begin(context.Background(), "key", nil)
+	close(release)
+
+	_, err := f.Await(context.Background())
+	assertNil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// late callers are served the lingering results regardless of their contexts
+	for i := 0; i < 100; i++ {
+		v, err := caller.Call(ctx, "key", nil)
+		assertNil(t, err)
+		assertEqual(t, 1, v)
+	}
+	assertEqual(t, uint64(0), caller.Stats().Abandoned)
+}
+
+func TestTTL(t *testing.T) {
+	t.Parallel()
+
+	var (
+		evicted    int64
+		executions int64
+	)
+
+	caller := NewCaller[string, int64](
+		WithTTL(mediumPause),
+		WithOnEvict(func(_ string, reason EvictReason) {
+			if reason == EvictExpired {
+				atomic.AddInt64(&evicted, 1)
+			}
+		}),
+	)
+
+	fn := func(context.Context) (int64, error) {
+		return atomic.AddInt64(&executions, 1), nil
+	}
+
+	for _, exp := range []int64{1, 1, 1} {
+		got, err := caller.Call(context.Background(), "key", fn)
+		assertNil(t, err)
+		assertEqual(t, got, exp)
+	}
+
+	time.Sleep(mediumPause)
+
+	got, err := caller.Call(context.Background(), "key", fn)
+	assertNil(t, err)
+	assertEqual(t, got, 2)
+	assertEqual(t, atomic.LoadInt64(&evicted), 1)
+}
+
+func TestTTLSweep(t *testing.T) {
+	t.Parallel()
+
+	evicted := make(chan EvictReason, 1)
+	caller := NewCaller[string, int](WithTTL(shortPause), WithOnEvict(func(_ string, reason EvictReason) {
+		evicted <- reason
+	}))
+
+	_, err := caller.Call(context.Background(), "key", func(context.Context) (int, error) { return 1, nil })
+	assertNil(t, err)
+
+	// expired calls are dropped without their keys being looked up again
+	assertEqual(t, EvictExpired, <-evicted)
+
+	caller.mu.Lock()
+	assertEqual(t, 0, len(caller.calls))
+	caller.mu.Unlock()
+}
+
+func TestTTLFor(t *testing.T) {
+	t.Parallel()
+
+	var executions int64
+
+	caller := NewCaller[string, int64](
+		WithTTL(time.Hour),
+		WithTTLFor(func(key string, _ int64, _ error) time.Duration {
+			if key == "untimed" {
+				return 0
+			}
+
+			return time.Hour
+		}),
+		WithLinger(2),
+	)
+
+	fn := func(context.Context) (int64, error) {
+		return atomic.AddInt64(&executions, 1), nil
+	}
+
+	call := func(key string, exp int64) {
+		t.Helper()
+
+		got, err := caller.Call(context.Background(), key, fn)
+		assertNil(t, err)
+		assertEqual(t, got, exp)
+	}
+
+	// calls which should not linger based on time still linger based on count
+	call("untimed", 1)
+	call("untimed", 1)
+	call("untimed", 1)
+	call("untimed", 2)
+
+	// and so do the ones which should
+	call("timed", 3)
+	call("timed", 3)
+	call("timed", 3)
+	call("timed", 4)
+}
+
+func TestTTLJitter(t *testing.T) {
+	t.Parallel()
+
+	const (
+		ttl      = time.Hour
+		fraction = .1
+	)
+
+	var (
+		caller = NewCaller[int, bool](WithTTL(ttl), WithTTLJitter(fraction))
+		spread = time.Duration(fraction * float64(ttl))
+		seen   = make(map[time.Duration]bool)
+	)
+
+	for i := 0; i < 100; i++ {
+		got := caller.ttl(i, false, nil)
+		assertTrue(t, got >= ttl-spread && got <= ttl+spread)
+
+		seen[got] = true
+	}
+	assertTrue(t, len(seen) > 1)
+}
+
+func TestCopy(t *testing.T) {
+	t.Parallel()
+
+	const key = "key"
+
+	var (
+		caller = NewCaller[string, []int](WithCopy(func(v []int) []int {
+			return append([]int(nil), v...)
+		}))
+		shared = []int{1, 2, 3}
+	)
+
+	f := caller.Begin(context.Background(), key, func(context.Context) ([]int, error) {
+		time.Sleep(shortPause)
+
+		return shared, nil
+	})
+
+	got, err := caller.TryCall(context.Background(), key)
+	assertNil(t, err)
+	got[0] = 0
+
+	got, err = f.Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, got[0], 1)
+	got[1] = 0
+
+	assertEqual(t, shared[0], 1)
+	assertEqual(t, shared[1], 2)
+}
+
+func TestAuthorize(t *testing.T) {
+	t.Parallel()
+
+	type tenantKey struct{}
+
+	errForbidden := errors.New("forbidden")
+
+	caller := NewCaller[string, bool](
+		WithLinger(1),
+		WithAuthorize(func(ctx context.Context, key string) error {
+			if tenant, _ := ctx.Value(tenantKey{}).(string); !strings.HasPrefix(key, tenant+"/") {
+				return errForbidden
+			}
+
+			return nil
+		}),
+	)
+
+	tenant := func(name string) context.Context {
+		return context.WithValue(context.Background(), tenantKey{}, name)
+	}
+
+	fn := func(context.Context) (bool, error) {
+		return true, nil
+	}
+
+	got, err := caller.Call(tenant("a"), "a/key", fn)
+	assertTrue(t, got)
+	assertNil(t, err)
+
+	// b may neither attach to a's lingering call nor start one of its own
+	got, err = caller.TryCall(tenant("b"), "a/key")
+	assertFalse(t, got)
+	assertErrorIs(t, err, errForbidden)
+
+	got, err = caller.Call(tenant("b"), "a/key", fn)
+	assertFalse(t, got)
+	assertErrorIs(t, err, errForbidden)
+
+	// a's call is still there for a
+	got, err = caller.TryCall(tenant("a"), "a/key")
+	assertTrue(t, got)
+	assertNil(t, err)
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	caller := NewCaller[string, bool](WithTimeout(shortPause))
+
+	fn := func(ctx context.Context) (bool, error) {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(mediumPause):
+			return true, nil
+		}
+	}
+
+	got, err := caller.Call(context.Background(), "key", fn)
+	assertFalse(t, got)
+	assertErrorIs(t, err, context.DeadlineExceeded)
+
+	got, err = caller.CallWithTimeout(context.Background(), "key", longPause, fn)
+	assertTrue(t, got)
+	assertNil(t, err)
+
+	got, err = caller.CallWithTimeout(context.Background(), "key", 0, fn)
+	assertTrue(t, got)
+	assertNil(t, err)
+}
+
+func TestTryCall(t *testing.T) {
+	t.Parallel()
+
+	const key = "key"
+
+	var caller Caller[string, bool]
+
+	got, err := caller.TryCall(context.Background(), key)
+	assertFalse(t, got)
+	assertErrorIs(t, err, ErrNotInFlight)
+
+	release := make(chan struct{})
+	f := caller.Begin(context.Background(), key, func(context.Context) (bool, error) {
+		<-release
+
+		return true, nil
+	})
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		got, err := caller.TryCall(context.Background(), key)
+		assertTrue(t, got)
+		assertNil(t, err)
+	}()
+
+	// let the attempt attach before releasing the call
+	for caller.Stats().Followers == 0 {
+		runtime.Gosched()
+	}
+	close(release)
+
+	_, _ = f.Await(context.Background())
+}
+
+func TestInterrupted(t *testing.T) {
+	t.Parallel()
+
+	errCause := errors.New("cause")
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errCause)
+
+	result := func() (bool, error) {
+		return false, errAssert
+	}
+
+	// the call has yet to complete
+	err := interrupted(ctx, make(chan struct{}), result)
+	assertErrorIs(t, err, errCause)
+	assertFalse(t, errors.Is(err, errAssert))
+
+	// the call has completed
+	err = interrupted(ctx, closedChan, result)
+	assertErrorIs(t, err, errCause)
+	assertErrorIs(t, err, errAssert)
+}
+
 func assertEqual[T comparable](t *testing.T, actual, expected T) {
 	t.Helper()
 
@@ -194,3 +563,65 @@ func assertFalse(t *testing.T, actual bool) {
 
 	t.Error("expected false")
 }
+
+func BenchmarkCall(b *testing.B) {
+	var caller Caller[int, int]
+
+	fn := func(context.Context) (int, error) {
+		return 1, nil
+	}
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = caller.Call(ctx, 0, fn)
+	}
+}
+
+func BenchmarkCallContended(b *testing.B) {
+	var caller Caller[int, int]
+
+	fn := func(context.Context) (int, error) {
+		// keep busy long enough for followers to attach
+		for started := time.Now(); time.Since(started) < time.Microsecond<<2; {
+			runtime.Gosched()
+		}
+
+		return 1, nil
+	}
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = caller.Call(ctx, 0, fn)
+		}
+	})
+}
+
+func BenchmarkCallContendedSpinWait(b *testing.B) {
+	caller := NewCaller[int, int](WithSpinWait(50 * time.Microsecond))
+
+	fn := func(context.Context) (int, error) {
+		// keep busy long enough for followers to attach
+		for started := time.Now(); time.Since(started) < time.Microsecond<<2; {
+			runtime.Gosched()
+		}
+
+		return 1, nil
+	}
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = caller.Call(ctx, 0, fn)
+		}
+	})
+}
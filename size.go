@@ -0,0 +1,53 @@
+package singleflight
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithMaxHeldSize configures the Caller to never hold results whose values are larger than limit, as measured by
+// sizeOf, so that a single, accidentally huge, value may not take up the memory the results held for other keys are
+// meant to. Such results are still served to the callers attached to their calls but neither linger, as configured
+// WithLinger, WithTTL or WithTTLFor, nor are kept as stale values, as configured WithStaleValues.
+//
+// Values are only measured when their results would otherwise be held. The results skipped are counted by the
+// Oversized statistic. CodecSize returns a sizeOf measuring values by the length of their encoding.
+//
+// WithMaxHeldSize panics in case limit is negative or sizeOf is nil.
+func WithMaxHeldSize[V any](limit int, sizeOf func(V) int) Option {
+	switch {
+	case limit < 0:
+		panic(fmt.Sprintf("singleflight: invalid maximum held size %d", limit))
+	case sizeOf == nil:
+		panic("singleflight: nil size function")
+	}
+
+	return valueOption[V](func(opts *valueOptions[V]) {
+		opts.maxHeldSize = limit
+		opts.sizeOf = sizeOf
+	})
+}
+
+// CodecSize returns a function measuring values by the length of their encoding via codec, for use with
+// WithMaxHeldSize. Values which fail to encode measure as 0.
+func CodecSize[V any](codec Codec[V]) func(V) int {
+	return func(v V) int {
+		data, err := codec.Marshal(v)
+		if err != nil {
+			return 0
+		}
+
+		return len(data)
+	}
+}
+
+// oversized reports whether v, the value of a call whose results should be held for the given TTL, is too large to
+// be held, as configured WithMaxHeldSize.
+func (caller *Caller[K, V]) oversized(v V, ttl time.Duration) bool {
+	sizeOf := caller.valueOpts.sizeOf
+	if sizeOf == nil || (caller.opts.linger <= 0 && ttl <= 0 && !caller.opts.keepStale) {
+		return false
+	}
+
+	return sizeOf(v) > caller.valueOpts.maxHeldSize
+}
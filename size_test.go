@@ -0,0 +1,75 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithMaxHeldSize(t *testing.T) {
+	t.Parallel()
+
+	caller := NewCaller[int, []byte](
+		WithLinger(1),
+		WithStaleValues(),
+		WithMaxHeldSize(4, func(v []byte) int { return len(v) }),
+	)
+
+	var executions int
+	fn := func(ctx context.Context) ([]byte, error) {
+		executions++
+
+		return make([]byte, caller.KeyFromContext(ctx)), nil
+	}
+
+	for i := 0; i < 2; i++ {
+		// oversized values are served but neither held
+		v, err := caller.Call(context.Background(), 8, fn)
+		assertNil(t, err)
+		assertEqual(t, 8, len(v))
+
+		// unlike the rest
+		v, err = caller.Call(context.Background(), 4, fn)
+		assertNil(t, err)
+		assertEqual(t, 4, len(v))
+	}
+	assertEqual(t, 3, executions)
+	assertEqual(t, uint64(2), caller.Stats().Oversized)
+
+	// nor kept as stale values
+	caller.mu.Lock()
+	_, kept := caller.stale[8]
+	caller.mu.Unlock()
+	assertFalse(t, kept)
+}
+
+func TestWithMaxHeldSizeMeasuresHeldValuesOnly(t *testing.T) {
+	t.Parallel()
+
+	var measured int
+	caller := NewCaller[string, string](WithMaxHeldSize(0, func(string) int {
+		measured++
+
+		return 1
+	}))
+
+	_, err := caller.Call(context.Background(), "key", func(context.Context) (string, error) { return "v", nil })
+	assertNil(t, err)
+	assertEqual(t, 0, measured)
+	assertEqual(t, uint64(0), caller.Stats().Oversized)
+}
+
+func TestCodecSize(t *testing.T) {
+	t.Parallel()
+
+	sizeOf := CodecSize[[]int](JSONCodec[[]int]{})
+	assertEqual(t, len("[1,2,3]"), sizeOf([]int{1, 2, 3}))
+
+	assertEqual(t, 0, CodecSize[chan int](JSONCodec[chan int]{})(make(chan int)))
+}
+
+func TestWithMaxHeldSizePanics(t *testing.T) {
+	t.Parallel()
+
+	assertPanics(t, func() { _ = WithMaxHeldSize(-1, func(string) int { return 0 }) })
+	assertPanics(t, func() { _ = WithMaxHeldSize[string](1, nil) })
+}
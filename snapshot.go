@@ -0,0 +1,146 @@
+package singleflight
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"time"
+)
+
+// snapshotVersion is the version of the format of the snapshots SaveSnapshot writes.
+const snapshotVersion = 1
+
+// snapshot is the serialized form of the results a Caller holds.
+type snapshot[K comparable] struct {
+	Version int
+	Entries []snapshotEntry[K]
+}
+
+// snapshotEntry is the serialized form of a held result.
+type snapshotEntry[K comparable] struct {
+	Key       K
+	Value     []byte    // encoded via the Codec passed to SaveSnapshot
+	Expires   time.Time // zero for results held for a number of late callers
+	Remaining int       // number of late callers the result may still serve, when counted
+}
+
+// SaveSnapshot writes to w a snapshot of the successful results the Caller holds for late callers, as configured
+// WithLinger, WithTTL or WithTTLFor, so that they may be restored via LoadSnapshot, across restarts of the process or
+// by another instance of it. Values are encoded via codec while keys are encoded via encoding/gob; they must be
+// encodable by it.
+//
+// Calls taking place and calls which failed are not saved.
+func (caller *Caller[K, V]) SaveSnapshot(w io.Writer, codec Codec[V]) error {
+	type held struct {
+		key       K
+		val       V
+		expires   time.Time
+		remaining int
+	}
+
+	now := time.Now()
+
+	caller.mu.Lock()
+	results := make([]held, 0, len(caller.calls))
+	for key, call := range caller.calls {
+		if !call.completed || call.err != nil || (!call.expires.IsZero() && !now.Before(call.expires)) {
+			continue
+		}
+
+		results = append(results, held{key, call.val, call.expires, call.remaining})
+	}
+	caller.mu.Unlock()
+
+	snap := snapshot[K]{
+		Version: snapshotVersion,
+		Entries: make([]snapshotEntry[K], 0, len(results)),
+	}
+
+	for _, result := range results {
+		data, err := codec.Marshal(result.val)
+		if err != nil {
+			return fmt.Errorf("singleflight: failed encoding the value for key %v: %w", result.key, err)
+		}
+
+		snap.Entries = append(snap.Entries, snapshotEntry[K]{
+			Key:       result.key,
+			Value:     data,
+			Expires:   result.expires,
+			Remaining: result.remaining,
+		})
+	}
+
+	return gob.NewEncoder(w).Encode(&snap)
+}
+
+// LoadSnapshot restores the results held in the snapshot SaveSnapshot wrote to r, with values decoded via codec. The
+// restored results are held for as long as they would have been held for by the Caller which saved them, save for
+// the results held for a number of late callers the Caller does not count, which are held for the number of callers
+// configured WithLinger.
+//
+// Results for keys the Caller already holds a call for, as well as results which have since expired, are skipped.
+// LoadSnapshot restores no results in case it fails.
+func (caller *Caller[K, V]) LoadSnapshot(r io.Reader, codec Codec[V]) error {
+	var snap snapshot[K]
+	if err := gob.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("singleflight: failed decoding snapshot: %w", err)
+	}
+
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("%w: %d", ErrSnapshotVersion, snap.Version)
+	}
+
+	vals := make([]V, len(snap.Entries))
+	for i, entry := range snap.Entries {
+		v, err := codec.Unmarshal(entry.Value)
+		if err != nil {
+			return fmt.Errorf("singleflight: failed decoding the value for key %v: %w", entry.Key, err)
+		}
+		vals[i] = v
+	}
+
+	now := time.Now()
+
+	caller.mu.Lock()
+	defer caller.mu.Unlock()
+
+	if caller.calls == nil {
+		caller.calls = make(map[K]*call[K, V], max(caller.opts.capacity, len(snap.Entries)))
+	}
+
+	for i, entry := range snap.Entries {
+		if _, ok := caller.calls[entry.Key]; ok || (!entry.Expires.IsZero() && !now.Before(entry.Expires)) {
+			continue
+		}
+
+		if entry.Expires.IsZero() && entry.Remaining <= 0 && caller.opts.linger <= 0 {
+			// the result would be held forever
+			continue
+		}
+
+		remaining := entry.Remaining
+		if linger := caller.opts.linger; linger > 0 && (remaining <= 0 || remaining > linger) {
+			remaining = linger
+		}
+
+		call := &call[K, V]{
+			caller:    caller,
+			key:       entry.Key,
+			id:        nextCallID(),
+			val:       vals[i],
+			completed: true,
+			remaining: remaining,
+			expires:   entry.Expires,
+		}
+		call.inv.complete(call.id)
+
+		caller.calls[entry.Key] = call
+		caller.inv.start(call.id)
+
+		if !entry.Expires.IsZero() {
+			caller.sweepAfter(call, entry.Expires.Sub(now))
+		}
+	}
+
+	return nil
+}
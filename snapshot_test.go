@@ -0,0 +1,124 @@
+package singleflight
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	src := NewCaller[string, int](WithTTL(time.Hour))
+
+	for key, v := range map[string]int{"a": 1, "b": 2} {
+		v := v
+
+		_, err := src.Call(context.Background(), key, func(context.Context) (int, error) { return v, nil })
+		assertNil(t, err)
+	}
+
+	_, err := src.Call(context.Background(), "failed", func(context.Context) (int, error) { return 0, errAssert })
+	assertError(t, err)
+
+	var buf bytes.Buffer
+	assertNil(t, src.SaveSnapshot(&buf, JSONCodec[int]{}))
+
+	dst := NewCaller[string, int](WithTTL(time.Hour))
+
+	_, err = dst.Call(context.Background(), "b", func(context.Context) (int, error) { return 3, nil })
+	assertNil(t, err)
+
+	assertNil(t, dst.LoadSnapshot(&buf, JSONCodec[int]{}))
+
+	v, err := dst.TryCall(context.Background(), "a")
+	assertNil(t, err)
+	assertEqual(t, v, 1)
+
+	// results the Caller already held are kept
+	v, err = dst.TryCall(context.Background(), "b")
+	assertNil(t, err)
+	assertEqual(t, v, 3)
+
+	_, err = dst.TryCall(context.Background(), "failed")
+	assertErrorIs(t, err, ErrNotInFlight)
+}
+
+func TestSnapshotLinger(t *testing.T) {
+	t.Parallel()
+
+	src := NewCaller[string, int](WithLinger(2))
+
+	_, err := src.Call(context.Background(), "key", func(context.Context) (int, error) { return 1, nil })
+	assertNil(t, err)
+
+	_, err = src.TryCall(context.Background(), "key")
+	assertNil(t, err)
+
+	var buf bytes.Buffer
+	assertNil(t, src.SaveSnapshot(&buf, GobCodec[int]{}))
+
+	dst := NewCaller[string, int](WithLinger(2))
+	assertNil(t, dst.LoadSnapshot(&buf, GobCodec[int]{}))
+
+	// the restored result serves the one late caller it had left
+	v, err := dst.TryCall(context.Background(), "key")
+	assertNil(t, err)
+	assertEqual(t, v, 1)
+
+	_, err = dst.TryCall(context.Background(), "key")
+	assertErrorIs(t, err, ErrNotInFlight)
+}
+
+func TestSnapshotLingerWithoutLinger(t *testing.T) {
+	t.Parallel()
+
+	src := NewCaller[string, int](WithLinger(2))
+
+	_, err := src.Call(context.Background(), "key", func(context.Context) (int, error) { return 1, nil })
+	assertNil(t, err)
+
+	var buf bytes.Buffer
+	assertNil(t, src.SaveSnapshot(&buf, GobCodec[int]{}))
+
+	// Callers which do not count late callers still count the restored result down
+	var dst Caller[string, int]
+	assertNil(t, dst.LoadSnapshot(&buf, GobCodec[int]{}))
+
+	for i := 0; i < 2; i++ {
+		v, err := dst.TryCall(context.Background(), "key")
+		assertNil(t, err)
+		assertEqual(t, v, 1)
+	}
+
+	_, err = dst.TryCall(context.Background(), "key")
+	assertErrorIs(t, err, ErrNotInFlight)
+}
+
+func TestLoadSnapshotErrors(t *testing.T) {
+	t.Parallel()
+
+	var caller Caller[string, int]
+
+	err := caller.LoadSnapshot(bytes.NewReader([]byte("garbage")), JSONCodec[int]{})
+	assertTrue(t, err != nil)
+
+	var buf bytes.Buffer
+	assertNil(t, gob.NewEncoder(&buf).Encode(&snapshot[string]{Version: snapshotVersion + 1}))
+	assertErrorIs(t, caller.LoadSnapshot(&buf, JSONCodec[int]{}), ErrSnapshotVersion)
+
+	buf.Reset()
+	assertNil(t, gob.NewEncoder(&buf).Encode(&snapshot[string]{
+		Version: snapshotVersion,
+		Entries: []snapshotEntry[string]{
+			{Key: "a", Value: []byte("1")},
+			{Key: "b", Value: []byte("garbage")},
+		},
+	}))
+	assertTrue(t, caller.LoadSnapshot(&buf, JSONCodec[int]{}) != nil)
+
+	_, err = caller.TryCall(context.Background(), "a")
+	assertErrorIs(t, err, ErrNotInFlight)
+}
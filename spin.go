@@ -0,0 +1,58 @@
+package singleflight
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// WithSpinWait configures the callers attaching to in-flight calls to spin, rather than block, while waiting for
+// their results, for as long as the calls have been in flight for less than limit. It suits Callers whose executions
+// typically complete within microseconds, for which parking and unparking the goroutines of followers dominates their
+// latency.
+//
+// Spinning is adaptive: followers attaching to calls which have been in flight for limit already, and are therefore
+// unlikely to complete soon, block right away, as do all followers when GOMAXPROCS is 1, in which case spinning would
+// only delay the execution they wait for.
+//
+// WithSpinWait panics in case limit is negative.
+func WithSpinWait(limit time.Duration) Option {
+	if limit < 0 {
+		panic(fmt.Sprintf("singleflight: invalid spin limit %s", limit))
+	}
+
+	return optionFunc(func(opts *options) {
+		opts.spinWait = limit
+	})
+}
+
+// spinYield is the number of checks spinning followers make between yielding their processor.
+const spinYield = 16
+
+// spin spins until the given call completes, for as long as it has been in flight for less than the limit configured
+// WithSpinWait and ctx is not done, and reports whether it completed.
+func (caller *Caller[K, V]) spin(ctx context.Context, call *call[K, V]) bool {
+	if runtime.GOMAXPROCS(0) < 2 {
+		return false
+	}
+
+	deadline := call.started.Add(caller.opts.spinWait)
+	for i := 1; ; i++ {
+		select {
+		case <-call.done:
+			return true
+		case <-ctx.Done():
+			return false
+		default:
+		}
+
+		if !time.Now().Before(deadline) {
+			return false
+		}
+
+		if i%spinYield == 0 {
+			runtime.Gosched()
+		}
+	}
+}
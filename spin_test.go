@@ -0,0 +1,143 @@
+package singleflight
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSpinWait(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOMAXPROCS(0) < 2 {
+		t.Skip("followers do not spin with GOMAXPROCS of 1")
+	}
+
+	caller := NewCaller[string, int](WithSpinWait(time.Hour))
+
+	release := make(chan struct{})
+	f := caller.Begin(context.Background(), "key", func(context.Context) (int, error) {
+		<-release
+
+		return 1, nil
+	})
+
+	call, _, err := caller.join(context.Background(), "key", false)
+	assertNil(t, err)
+
+	spun := make(chan bool)
+	go func() { spun <- caller.spin(context.Background(), call) }()
+
+	time.Sleep(shortPause)
+	close(release)
+
+	assertTrue(t, <-spun)
+
+	v, err := f.Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, 1, v)
+}
+
+func TestSpinWaitLimit(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOMAXPROCS(0) < 2 {
+		t.Skip("followers do not spin with GOMAXPROCS of 1")
+	}
+
+	var (
+		caller  = NewCaller[string, int](WithSpinWait(shortPause))
+		release = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+	defer wg.Wait()
+	defer close(release)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		_, _ = caller.Call(context.Background(), "key", func(context.Context) (int, error) {
+			<-release
+
+			return 1, nil
+		})
+	}()
+
+	for caller.running.Load() == 0 {
+		runtime.Gosched()
+	}
+
+	call, _, err := caller.join(context.Background(), "key", false)
+	assertNil(t, err)
+
+	// followers stop spinning once the call has been in flight for the limit
+	assertFalse(t, caller.spin(context.Background(), call))
+	assertTrue(t, time.Since(call.started) >= shortPause)
+
+	// and block right away past it
+	started := time.Now()
+	assertFalse(t, caller.spin(context.Background(), call))
+	assertTrue(t, time.Since(started) < shortPause)
+
+	caller.abandon(context.Background(), call)
+}
+
+func TestSpinWaitCancellation(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOMAXPROCS(0) < 2 {
+		t.Skip("followers do not spin with GOMAXPROCS of 1")
+	}
+
+	caller := NewCaller[string, int](WithSpinWait(time.Hour))
+
+	release := make(chan struct{})
+	defer close(release)
+
+	_ = caller.Begin(context.Background(), "key", func(context.Context) (int, error) {
+		<-release
+
+		return 1, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), shortPause)
+	defer cancel()
+
+	// followers stop spinning once their contexts are done
+	_, err := caller.Call(ctx, "key", nil)
+	assertErrorIs(t, err, context.DeadlineExceeded)
+	assertEqual(t, uint64(1), caller.Stats().Abandoned)
+}
+
+func TestWithSpinWaitPanicsOnNegativeLimits(t *testing.T) {
+	t.Parallel()
+
+	assertPanics(t, func() { _ = WithSpinWait(-time.Second) })
+}
+
+func TestSpinWaitSingleProcessor(t *testing.T) {
+	if runtime.GOMAXPROCS(0) > 1 {
+		t.Skip("GOMAXPROCS is greater than 1")
+	}
+
+	caller := NewCaller[string, int](WithSpinWait(time.Hour))
+
+	release := make(chan struct{})
+	defer close(release)
+
+	_ = caller.Begin(context.Background(), "key", func(context.Context) (int, error) {
+		<-release
+
+		return 1, nil
+	})
+
+	call, _, err := caller.join(context.Background(), "key", false)
+	assertNil(t, err)
+
+	// spinning would only delay the execution
+	assertFalse(t, caller.spin(context.Background(), call))
+	caller.abandon(context.Background(), call)
+}
@@ -0,0 +1,74 @@
+package singleflight
+
+import "context"
+
+// WithStaleValues configures the Caller to retain the value of the latest successful execution for every key, so
+// that CallStale may serve it once the call it resulted from is gone. Since values are retained for every key the
+// Caller has been called with, regardless of Forget and Purge, memory usage grows with the number of distinct keys.
+func WithStaleValues() Option {
+	return optionFunc(func(opts *options) {
+		opts.keepStale = true
+	})
+}
+
+// staleValue is the value of the latest successful execution for a key.
+type staleValue[V any] struct {
+	val V
+	id  CallID // of the execution which produced the value
+}
+
+// keep retains the value of the given completed call, unless a newer call has produced a value already.
+//
+// caller.mu must be held.
+func (caller *Caller[K, V]) keep(call *call[K, V]) {
+	if prev, ok := caller.stale[call.key]; ok && prev.id > call.id {
+		return
+	}
+
+	if caller.stale == nil {
+		caller.stale = make(map[K]staleValue[V])
+	}
+	caller.stale[call.key] = staleValue[V]{call.val, call.id}
+}
+
+// CallStale is like Call but, in case the Caller retains a value for the key, as configured WithStaleValues, and it
+// holds no completed call to serve instead, it returns that value immediately, reporting it as stale, while fn
+// refreshes it in the background. It only waits in case there is nothing at all to serve.
+//
+// The refresh is carried out like Begin carries out calls, bound by the values but not the cancellation of ctx, so
+// that it outlives the caller which triggered it. Concurrent callers share it like they share any other call.
+func (caller *Caller[K, V]) CallStale(
+	ctx context.Context,
+	key K,
+	fn func(context.Context) (V, error),
+) (_ V, stale bool, _ error) {
+	if !hashable(key) {
+		var zero V
+		return zero, false, ErrUncomparableKey
+	}
+
+	caller.mu.Lock()
+	prev, retained := caller.stale[key]
+	caller.mu.Unlock()
+
+	if !retained {
+		v, err := caller.Call(ctx, key, fn)
+
+		return v, false, err
+	}
+
+	f := caller.Begin(context.WithoutCancel(ctx), key, fn)
+	if v, err, ok := f.TryGet(); ok {
+		// either the caller may not join the call or it was served a completed call
+		return v, false, err
+	}
+
+	// the caller does not wait for the refresh
+	f.leave(false)
+
+	if caller.valueOpts.copy != nil {
+		return caller.valueOpts.copy(prev.val), true, nil
+	}
+
+	return prev.val, true, nil
+}
@@ -0,0 +1,121 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCallStale(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller  = NewCaller[string, int](WithStaleValues())
+		release = make(chan struct{})
+	)
+
+	fn := func(v int) func(context.Context) (int, error) {
+		return func(context.Context) (int, error) {
+			<-release
+
+			return v, nil
+		}
+	}
+
+	// there's nothing to serve; wait
+	close(release)
+	v, stale, err := caller.CallStale(context.Background(), "key", fn(1))
+	assertNil(t, err)
+	assertFalse(t, stale)
+	assertEqual(t, v, 1)
+
+	// the retained value is served while the refresh proceeds
+	release = make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	v, stale, err = caller.CallStale(ctx, "key", fn(2))
+	cancel()
+	assertNil(t, err)
+	assertTrue(t, stale)
+	assertEqual(t, v, 1)
+
+	// concurrent callers share the refresh
+	f := caller.Begin(context.Background(), "key", nil)
+	close(release)
+
+	// the refresh outlives the context of the caller which triggered it
+	v, err = f.Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, v, 2)
+
+	// failed refreshes leave the retained value intact
+	_, err = caller.Call(context.Background(), "key", func(context.Context) (int, error) {
+		return 0, errAssert
+	})
+	assertError(t, err)
+
+	release = make(chan struct{})
+	v, stale, err = caller.CallStale(context.Background(), "key", fn(3))
+	assertNil(t, err)
+	assertTrue(t, stale)
+	assertEqual(t, v, 2)
+	close(release)
+}
+
+func TestCallStaleServesCompletedCalls(t *testing.T) {
+	t.Parallel()
+
+	caller := NewCaller[string, int](WithStaleValues(), WithLinger(1))
+
+	_, err := caller.Call(context.Background(), "key", func(context.Context) (int, error) { return 1, nil })
+	assertNil(t, err)
+
+	// the lingering call is served as fresh
+	v, stale, err := caller.CallStale(context.Background(), "key", nil)
+	assertNil(t, err)
+	assertFalse(t, stale)
+	assertEqual(t, v, 1)
+}
+
+func TestCallStaleAuthorizes(t *testing.T) {
+	t.Parallel()
+
+	var (
+		errDenied = errors.New("denied")
+		caller    = NewCaller[string, int](
+			WithStaleValues(),
+			WithAuthorize(func(ctx context.Context, _ string) error {
+				if ctx.Value(errDenied) != nil {
+					return errDenied
+				}
+
+				return nil
+			}),
+		)
+	)
+
+	_, err := caller.Call(context.Background(), "key", func(context.Context) (int, error) { return 1, nil })
+	assertNil(t, err)
+
+	ctx := context.WithValue(context.Background(), errDenied, true) //nolint:staticcheck // test-only key
+	_, stale, err := caller.CallStale(ctx, "key", nil)
+	assertErrorIs(t, err, errDenied)
+	assertFalse(t, stale)
+}
+
+func TestCallStaleWithoutStaleValues(t *testing.T) {
+	t.Parallel()
+
+	var caller Caller[string, int]
+
+	for i := 1; i <= 2; i++ {
+		i := i
+
+		v, stale, err := caller.CallStale(context.Background(), "key", func(context.Context) (int, error) {
+			return i, nil
+		})
+		assertNil(t, err)
+		assertFalse(t, stale)
+		assertEqual(t, v, i)
+	}
+}
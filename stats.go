@@ -0,0 +1,138 @@
+package singleflight
+
+import "time"
+
+// Stats describes the activity of a Caller.
+type Stats struct {
+	// Followers is the number of callers which attached to an in-flight call.
+	Followers uint64
+
+	// Abandoned is the number of followers which stopped waiting for the results of the call they had attached
+	// to because their context was canceled.
+	Abandoned uint64
+
+	// QueueTimeouts is the number of executions dropped for having remained queued on the pool of the Caller for
+	// longer than the timeout configured WithQueueTimeout.
+	QueueTimeouts uint64
+
+	// Queued is the number of executions currently queued on the pool of the Caller, as configured WithWorkers. It
+	// is only reported by Stats.
+	Queued uint64
+
+	// Running is the number of executions currently taking place, including the ones which published their results
+	// via Publish but have yet to return. It is only reported by Stats.
+	Running uint64
+
+	// Oversized is the number of results which were not held for their values exceeding the size configured
+	// WithMaxHeldSize.
+	Oversized uint64
+}
+
+// add adds the given statistics to s.
+func (s *Stats) add(o Stats) {
+	s.Followers += o.Followers
+	s.Abandoned += o.Abandoned
+	s.QueueTimeouts += o.QueueTimeouts
+	s.Queued += o.Queued
+	s.Running += o.Running
+	s.Oversized += o.Oversized
+}
+
+// Stats returns the statistics of the Caller.
+func (caller *Caller[K, V]) Stats() Stats {
+	caller.mu.Lock()
+	stats := caller.stats
+	caller.mu.Unlock()
+
+	if caller.pooled() {
+		stats.Queued = uint64(caller.pool.depth())
+	}
+	stats.Running = uint64(caller.running.Load())
+
+	return stats
+}
+
+// KeyStats returns the statistics of the Caller for the given key. It reports false in case no statistics are
+// available for the key, which is always the case for Callers not configured WithKeyStats.
+func (caller *Caller[K, V]) KeyStats(key K) (Stats, bool) {
+	if !hashable(key) {
+		return Stats{}, false
+	}
+
+	caller.mu.Lock()
+	defer caller.mu.Unlock()
+
+	if state, ok := caller.keys[key]; ok && caller.opts.trackKeys {
+		return state.stats, true
+	}
+
+	return Stats{}, false
+}
+
+// track applies fn to the statistics of the Caller as well as, when tracked, to the ones of the given key.
+//
+// caller.mu must be held.
+func (caller *Caller[K, V]) track(key K, fn func(*Stats)) {
+	fn(&caller.stats)
+
+	if caller.opts.trackKeys {
+		fn(&caller.key(key).stats)
+	}
+}
+
+// EstimatedDuration returns the exponentially weighted moving average of the durations of the executions for the
+// given key. It reports false in case no estimate is available for the key, which is always the case for Callers not
+// configured WithDurationEstimates.
+func (caller *Caller[K, V]) EstimatedDuration(key K) (time.Duration, bool) {
+	if !hashable(key) {
+		return 0, false
+	}
+
+	caller.mu.Lock()
+	defer caller.mu.Unlock()
+
+	if state, ok := caller.keys[key]; ok && state.estimated {
+		return state.estimate, true
+	}
+
+	return 0, false
+}
+
+// estimate folds the given execution duration into the estimate for the given key.
+//
+// caller.mu must be held.
+func (caller *Caller[K, V]) estimate(key K, d time.Duration) {
+	state := caller.key(key)
+	if !state.estimated {
+		state.estimate, state.estimated = d, true
+
+		return
+	}
+
+	state.estimate += time.Duration(caller.opts.durationSmoothing * float64(d-state.estimate))
+}
+
+// keyState holds what a Caller tracks per key.
+type keyState struct {
+	stats Stats
+
+	estimate  time.Duration
+	estimated bool
+}
+
+// key returns the state of the given key, allocating it when needed.
+//
+// caller.mu must be held.
+func (caller *Caller[K, V]) key(key K) *keyState {
+	state, ok := caller.keys[key]
+	if !ok {
+		if caller.keys == nil {
+			caller.keys = make(map[K]*keyState, caller.opts.capacity)
+		}
+
+		state = new(keyState)
+		caller.keys[key] = state
+	}
+
+	return state
+}
@@ -0,0 +1,151 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	const key = "key"
+
+	var (
+		caller = NewCaller[string, bool](WithKeyStats())
+		wg     sync.WaitGroup
+	)
+
+	fn := func(context.Context) (bool, error) {
+		time.Sleep(mediumPause)
+
+		return true, nil
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		_, _ = caller.Call(context.Background(), key, fn)
+	}()
+
+	for _, timeout := range []time.Duration{shortPause, longPause} {
+		timeout := timeout
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			time.Sleep(shortPause >> 1)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			_, _ = caller.Call(ctx, key, fn)
+		}()
+	}
+
+	wg.Wait()
+
+	exp := Stats{
+		Followers: 2,
+		Abandoned: 1,
+	}
+	assertEqual(t, caller.Stats(), exp)
+
+	got, ok := caller.KeyStats(key)
+	assertTrue(t, ok)
+	assertEqual(t, got, exp)
+
+	_, ok = caller.KeyStats(key + "1")
+	assertFalse(t, ok)
+}
+
+func TestEstimatedDuration(t *testing.T) {
+	t.Parallel()
+
+	const key = "key"
+
+	caller := NewCaller[string, bool](WithDurationEstimates(.5))
+
+	_, ok := caller.EstimatedDuration(key)
+	assertFalse(t, ok)
+
+	call := func(d time.Duration) time.Duration {
+		t.Helper()
+
+		_, _ = caller.Call(context.Background(), key, func(context.Context) (bool, error) {
+			time.Sleep(d)
+
+			return true, nil
+		})
+
+		got, ok := caller.EstimatedDuration(key)
+		assertTrue(t, ok)
+
+		return got
+	}
+
+	// the first execution seeds the estimate
+	first := call(mediumPause)
+	assertTrue(t, first >= mediumPause && first < longPause)
+
+	// the second one moves it halfway towards its duration
+	second := call(shortPause)
+	assertTrue(t, second < first && second > shortPause)
+
+	_, ok = caller.EstimatedDuration(key + "1")
+	assertFalse(t, ok)
+
+	// estimating durations does not enable the per-key statistics
+	_, ok = caller.KeyStats(key)
+	assertFalse(t, ok)
+}
+
+func TestDeadlineAdmission(t *testing.T) {
+	t.Parallel()
+
+	const key = "key"
+
+	caller := NewCaller[string, bool](WithDurationEstimates(1), WithDeadlineAdmission())
+
+	fn := func(context.Context) (bool, error) {
+		time.Sleep(mediumPause)
+
+		return true, nil
+	}
+
+	// seed the estimate
+	_, _ = caller.Call(context.Background(), key, fn)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		_, _ = caller.Call(context.Background(), key, fn)
+	}()
+	time.Sleep(shortPause >> 1)
+
+	// callers with too short a deadline are refused
+	ctx, cancel := context.WithTimeout(context.Background(), shortPause)
+	defer cancel()
+
+	got, err := caller.Call(ctx, key, fn)
+	assertFalse(t, got)
+	assertErrorIs(t, err, ErrWouldMissDeadline)
+	assertErrorIs(t, err, context.DeadlineExceeded)
+
+	// while callers with enough of a deadline, or none at all, attach
+	ctx, cancel = context.WithTimeout(context.Background(), longPause)
+	defer cancel()
+
+	got, err = caller.Call(ctx, key, fn)
+	assertTrue(t, got)
+	assertNil(t, err)
+
+	assertEqual(t, caller.Stats().Followers, 1)
+}
@@ -0,0 +1,44 @@
+package singleflight
+
+import (
+	"context"
+	"fmt"
+	"runtime/trace"
+)
+
+// traceExecution starts, in case the execution tracer is enabled, the runtime/trace task the execution of the given
+// call is carried out under, along with the region of the execution itself, logging the key and ID of the call. It
+// returns the context of the task, which the execution should be derived from, and the function ending both.
+func traceExecution[K comparable](ctx context.Context, key K, id CallID) (context.Context, func()) {
+	if !trace.IsEnabled() {
+		return ctx, untraced
+	}
+
+	ctx, task := trace.NewTask(ctx, "singleflight.call")
+	trace.Log(ctx, "singleflight.key", fmt.Sprint(key))
+	trace.Log(ctx, "singleflight.id", fmt.Sprint(id))
+
+	region := trace.StartRegion(ctx, "singleflight.execute")
+
+	return ctx, func() {
+		region.End()
+		task.End()
+	}
+}
+
+// traceWait starts, in case the execution tracer is enabled, the runtime/trace region of the wait of the caller ctx
+// belongs to for the results of the given call, logging the key and ID of the call so that the wait may be matched to
+// the task of the execution. It returns the function ending the region.
+func traceWait[K comparable](ctx context.Context, key K, id CallID) func() {
+	if !trace.IsEnabled() {
+		return untraced
+	}
+
+	trace.Log(ctx, "singleflight.key", fmt.Sprint(key))
+	trace.Log(ctx, "singleflight.id", fmt.Sprint(id))
+
+	return trace.StartRegion(ctx, "singleflight.wait").End
+}
+
+// untraced is the function ending the traces of executions and waits which were not traced.
+func untraced() {}
@@ -0,0 +1,54 @@
+package singleflight
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"runtime/trace"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Skipf("execution tracer unavailable: %v", err)
+	}
+
+	var (
+		caller  Caller[string, int]
+		entered = make(chan struct{})
+		release = make(chan struct{})
+	)
+
+	f := caller.Begin(context.Background(), "traced key", func(context.Context) (int, error) {
+		close(entered)
+		<-release
+
+		return 1, nil
+	})
+	<-entered
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		_, _ = caller.Call(context.Background(), "traced key", nil)
+	}()
+
+	for caller.Stats().Followers == 0 {
+		runtime.Gosched()
+	}
+	close(release)
+	<-done
+
+	_, err := f.Await(context.Background())
+	assertNil(t, err)
+
+	trace.Stop()
+
+	for _, s := range []string{"singleflight.call", "singleflight.execute", "singleflight.wait", "traced key"} {
+		assertTrue(t, bytes.Contains(buf.Bytes(), []byte(s)))
+	}
+}
@@ -0,0 +1,94 @@
+package singleflight
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// CallerE is a Caller whose functions report failures via errors of type E, such as the rich error types of a domain,
+// which it serves to callers as such rather than as plain errors they would have to assert the type of.
+//
+// The zero value of E, being nil for pointer and interface types, denotes success. Failures which do not originate
+// from the functions, such as contexts being done or executions panicking, are reported via plain errors.
+//
+// CallerE embeds a Caller, via which it may be inspected and its calls forgotten. Calls made via the embedded Caller
+// share the calls made via the CallerE.
+type CallerE[K comparable, V any, E error] struct {
+	Caller[K, V]
+}
+
+// NewCallerE returns a CallerE configured by the given options.
+func NewCallerE[K comparable, V any, E error](opts ...Option) *CallerE[K, V, E] {
+	caller := new(CallerE[K, V, E])
+	caller.Caller.configure(opts)
+
+	return caller
+}
+
+// Call calls fn like Caller.Call does. It returns the value fn returned, along with either the error of type E fn
+// returned or any other error the call failed with.
+func (caller *CallerE[K, V, E]) Call(ctx context.Context, key K, fn func(context.Context) (V, E)) (V, E, error) {
+	v, err := caller.Caller.Call(ctx, key, caller.wrap(fn))
+
+	return caller.unwrap(v, err)
+}
+
+// CallWithTimeout is like Call but it bounds the execution of fn like Caller.CallWithTimeout does.
+func (caller *CallerE[K, V, E]) CallWithTimeout(
+	ctx context.Context,
+	key K,
+	timeout time.Duration,
+	fn func(context.Context) (V, E),
+) (V, E, error) {
+	v, err := caller.Caller.CallWithTimeout(ctx, key, timeout, caller.wrap(fn))
+
+	return caller.unwrap(v, err)
+}
+
+// TryCall is like Call but it only attaches to a call already taking place, or lingering, for the given key, like
+// Caller.TryCall does.
+func (caller *CallerE[K, V, E]) TryCall(ctx context.Context, key K) (V, E, error) {
+	v, err := caller.Caller.TryCall(ctx, key)
+
+	return caller.unwrap(v, err)
+}
+
+// typedError carries the errors of type E functions return through the shared call.
+type typedError[E error] struct {
+	err E
+}
+
+func (e *typedError[E]) Error() string {
+	return e.err.Error()
+}
+
+func (e *typedError[E]) Unwrap() error {
+	return e.err
+}
+
+func (*CallerE[K, V, E]) wrap(fn func(context.Context) (V, E)) func(context.Context) (V, error) {
+	return func(ctx context.Context) (V, error) {
+		v, err := fn(ctx)
+		if isZero(err) {
+			return v, nil
+		}
+
+		return v, &typedError[E]{err: err}
+	}
+}
+
+func (*CallerE[K, V, E]) unwrap(v V, err error) (V, E, error) {
+	var zero E
+
+	if typed, ok := err.(*typedError[E]); ok { //nolint:errorlint // only the errors wrap returns are of interest
+		return v, typed.err, nil
+	}
+
+	return v, zero, err
+}
+
+// isZero reports whether err is the zero value of its type.
+func isZero[E error](err E) bool {
+	return reflect.ValueOf(&err).Elem().IsZero()
+}
@@ -0,0 +1,82 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type notFoundError struct {
+	name string
+}
+
+func (e *notFoundError) Error() string {
+	return e.name + " not found"
+}
+
+func TestCallerE(t *testing.T) {
+	t.Parallel()
+
+	caller := NewCallerE[string, int, *notFoundError](WithLinger(1))
+
+	v, typed, err := caller.Call(context.Background(), "found", func(context.Context) (int, *notFoundError) {
+		return 1, nil
+	})
+	assertEqual(t, v, 1)
+	assertTrue(t, typed == nil)
+	assertNil(t, err)
+
+	exp := &notFoundError{name: "missing"}
+	v, typed, err = caller.Call(context.Background(), "missing", func(context.Context) (int, *notFoundError) {
+		return 0, exp
+	})
+	assertEqual(t, v, 0)
+	assertEqual(t, typed, exp)
+	assertNil(t, err)
+
+	// the lingering call serves the typed error as well
+	_, typed, err = caller.TryCall(context.Background(), "missing")
+	assertEqual(t, typed, exp)
+	assertNil(t, err)
+
+	_, typed, err = caller.TryCall(context.Background(), "other")
+	assertTrue(t, typed == nil)
+	assertErrorIs(t, err, ErrNotInFlight)
+}
+
+func TestCallerEInterrupted(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller  CallerE[string, int, *notFoundError]
+		release = make(chan struct{})
+		exp     = &notFoundError{name: "key"}
+	)
+
+	f := caller.Begin(context.Background(), "key", func(context.Context) (int, error) {
+		<-release
+
+		return 0, exp
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, typed, err := caller.CallWithTimeout(ctx, "key", 0, nil)
+	assertTrue(t, typed == nil)
+	assertErrorIs(t, err, context.Canceled)
+
+	close(release)
+
+	_, err = f.Await(context.Background())
+	assertErrorIs(t, err, exp)
+}
+
+func TestIsZero(t *testing.T) {
+	t.Parallel()
+
+	assertTrue(t, isZero[error](nil))
+	assertTrue(t, isZero[*notFoundError](nil))
+	assertFalse(t, isZero[error](errors.New("error")))
+	assertFalse(t, isZero(&notFoundError{}))
+}
@@ -0,0 +1,71 @@
+package singleflight
+
+import "context"
+
+// threshold is a channel to close once the number of callers waiting for the results of a call drops below n.
+type threshold struct {
+	n  int
+	ch chan struct{}
+}
+
+// waitersWatcher is implemented by calls.
+type waitersWatcher interface {
+	waitersBelow(n int) <-chan struct{}
+}
+
+// WaitersBelow returns a channel which is closed once fewer than n callers wait for the results of the execution ctx
+// belongs to, the innermost one in case executions are nested, so that expensive executions may abort or downgrade
+// their work once hardly anyone still wants their results.
+//
+// The callers waiting for the results of an execution are the ones attached to its call which have not stopped
+// waiting, such as because their contexts were done. They include the caller which started the call, even while it
+// carries out the execution, so that WaitersBelow(ctx, 2) is closed once every other caller stopped waiting.
+//
+// The returned channel is never closed in case ctx belongs to no execution or the execution has published its
+// results.
+func WaitersBelow(ctx context.Context, n int) <-chan struct{} {
+	if call, ok := ctx.Value(executionContextKey{}).(waitersWatcher); ok {
+		return call.waitersBelow(n)
+	}
+
+	return nil
+}
+
+func (call *call[K, V]) waitersBelow(n int) <-chan struct{} {
+	call.caller.mu.Lock()
+	defer call.caller.mu.Unlock()
+
+	switch {
+	case call.completed:
+		return nil
+	case call.waiters < n:
+		return closedChan
+	}
+
+	ch := make(chan struct{})
+	extra := call.extras()
+	extra.thresholds = append(extra.thresholds, threshold{n, ch})
+
+	return ch
+}
+
+// crossed closes the channels of the thresholds the number of callers waiting for the results of the call has
+// dropped below.
+//
+// The Caller's mutex must be held.
+func (call *call[K, V]) crossed() {
+	extra := call.extra
+	if extra == nil {
+		return
+	}
+
+	thresholds := extra.thresholds[:0]
+	for _, t := range extra.thresholds {
+		if call.waiters < t.n {
+			close(t.ch)
+		} else {
+			thresholds = append(thresholds, t)
+		}
+	}
+	extra.thresholds = thresholds
+}
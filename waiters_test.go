@@ -0,0 +1,134 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestWaitersBelow(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller  Caller[string, int]
+		errIdle = errors.New("idle")
+		ready   = make(chan struct{})
+		below   = make(chan (<-chan struct{}))
+		release = make(chan struct{})
+	)
+
+	f := caller.Begin(context.Background(), "key", func(ctx context.Context) (int, error) {
+		// the starter of the call counts as waiting for it
+		select {
+		case <-WaitersBelow(ctx, 1):
+			t.Error("expected the starter of the call to be waiting")
+		default:
+		}
+
+		<-ready
+		below <- WaitersBelow(ctx, 2)
+		<-release
+
+		return 0, errIdle
+	})
+
+	const followers = 2
+
+	var cancels []context.CancelFunc
+	for i := 0; i < followers; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancels = append(cancels, cancel)
+
+		go func() { _, _ = caller.Call(ctx, "key", nil) }()
+	}
+
+	for caller.Stats().Followers < followers {
+		runtime.Gosched()
+	}
+
+	close(ready)
+	ch := <-below
+
+	cancels[0]()
+	for caller.Stats().Abandoned < 1 {
+		runtime.Gosched()
+	}
+
+	select {
+	case <-ch:
+		t.Fatal("expected a follower to be waiting")
+	default:
+	}
+
+	cancels[1]()
+	<-ch
+
+	close(release)
+
+	_, err := f.Await(context.Background())
+	assertErrorIs(t, err, errIdle)
+}
+
+func TestWaitersBelowOutsideExecutions(t *testing.T) {
+	t.Parallel()
+
+	assertTrue(t, WaitersBelow(context.Background(), 1) == nil)
+}
+
+func TestWaitersBelowFutures(t *testing.T) {
+	t.Parallel()
+
+	var (
+		caller  = NewCaller[string, int](WithStaleValues())
+		ready   = make(chan struct{})
+		below   = make(chan (<-chan struct{}))
+		release = make(chan struct{})
+	)
+
+	fn := func(ctx context.Context) (int, error) {
+		<-ready
+		below <- WaitersBelow(ctx, 1)
+		<-release
+
+		return 1, nil
+	}
+
+	// retain a stale value for the key
+	_, err := caller.Call(context.Background(), "key", func(context.Context) (int, error) { return 0, nil })
+	assertNil(t, err)
+
+	// callers served stale values do not wait for the refresh
+	v, stale, err := caller.CallStale(context.Background(), "key", fn)
+	assertNil(t, err)
+	assertTrue(t, stale)
+	assertEqual(t, 0, v)
+
+	// while callers awaiting futures do, until their contexts are done
+	f := caller.Begin(context.Background(), "key", nil)
+
+	close(ready)
+	ch := <-below
+
+	select {
+	case <-ch:
+		t.Fatal("expected the future to be waited for")
+	default:
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for i := 0; i < 2; i++ {
+		_, err = f.Await(ctx)
+		assertErrorIs(t, err, context.Canceled)
+	}
+	<-ch
+
+	close(release)
+
+	v, err = f.Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, 1, v)
+	assertEqual(t, uint64(1), caller.Stats().Abandoned)
+}